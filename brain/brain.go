@@ -0,0 +1,59 @@
+package brain
+
+import (
+	"math/rand"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+)
+
+// Sensors report what an organism senses about itself and its surroundings
+// at the start of a cycle. Nothing in the world changes while organisms
+// decide their actions, so each sense is only checked if a Brain asks for it.
+type Sensors interface {
+	// Sensors check decision tree Conditions
+	d.Checker
+	// Health returns the organism's health as a fraction of its size
+	Health() float64
+	// Ph returns the ph at the organism's location, scaled from 0 (MinPh) to
+	// 1 (MaxPh)
+	Ph() float64
+	// FoodAhead returns the value of any food directly ahead, scaled from 0
+	// (none) to 1 (MaxFoodValue)
+	FoodAhead() float64
+}
+
+// Brain decides which action an organism takes each cycle
+type Brain interface {
+	// Decide returns the action to take this cycle
+	Decide(s Sensors, r *rand.Rand) d.Action
+	// Copy returns an identical copy of the Brain that can be changed
+	// without affecting the original
+	Copy() Brain
+	// Mutate returns a randomly changed copy of the Brain
+	Mutate(g *config.Globals, r *rand.Rand) Brain
+	// Equal returns true if the other Brain is the same kind and identical,
+	// as when a child inherits its parent's unchanged
+	Equal(other Brain) bool
+	// Size returns the number of nodes in the Brain, each of which costs
+	// the organism health every cycle
+	Size() int
+	// Describe returns a readable, multi-line description of the Brain
+	Describe() string
+}
+
+// New returns a new random Brain of the configured type. A mixed population
+// gives each new organism either type with equal chance.
+func New(g *config.Globals, r *rand.Rand) Brain {
+	brainType := g.BrainType
+	if brainType == config.BrainMixed {
+		brainType = config.BrainTree
+		if r.Intn(2) == 0 {
+			brainType = config.BrainNetwork
+		}
+	}
+	if brainType == config.BrainNetwork {
+		return NewNetwork(g, r)
+	}
+	return NewTree(g, r)
+}
@@ -0,0 +1,298 @@
+package brain
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+)
+
+// networkConditions are the Conditions a Network senses, each as 1 if true
+// or 0 if not. Those with parameters are sensed as values instead (see
+// Sensors), and random ones are replaced by a single random input.
+var networkConditions = []d.Condition{
+	d.CanMove,
+	d.IsFoodAhead,
+	d.IsFoodLeft,
+	d.IsFoodRight,
+	d.IsOrganismAhead,
+	d.IsBiggerOrganismAhead,
+	d.IsRelatedOrganismAhead,
+	d.IsOrganismLeft,
+	d.IsRelatedOrganismLeft,
+	d.IsOrganismRight,
+	d.IsRelatedOrganismRight,
+	d.IsHealthyPhHere,
+}
+
+// networkValues names the inputs that follow the sensed conditions and
+// memory flags
+var networkValues = []string{"Health", "Ph", "Food Ahead Value", "Random"}
+
+// Network is a Brain made of a small feed-forward neural network with a
+// single hidden layer. Its inputs are the organism's senses and each of its
+// outputs is an action, with the highest output chosen each cycle. The
+// topology is fixed when the Network is created and only its weights change.
+type Network struct {
+	Flags  int         // number of memory flags sensed and changed
+	Hidden [][]float64 // weights into each hidden node, from each input and then a bias
+	Output [][]float64 // weights into each output, from each hidden node and then a bias
+}
+
+// NewNetwork returns a Network with NetworkHiddenNodes hidden nodes, sensing
+// and changing the configured number of memory flags, with random weights
+func NewNetwork(g *config.Globals, r *rand.Rand) *Network {
+	flags := g.MemoryFlags
+	if flags < 0 {
+		flags = 0
+	} else if flags > d.MaxMemoryFlags {
+		flags = d.MaxMemoryFlags
+	}
+	hiddenNodes := g.NetworkHiddenNodes
+	if hiddenNodes < 1 {
+		hiddenNodes = 1
+	}
+
+	n := &Network{Flags: flags}
+	n.Hidden = randomWeights(hiddenNodes, n.inputCount()+1, r)
+	n.Output = randomWeights(len(n.actions()), hiddenNodes+1, r)
+	return n
+}
+
+func randomWeights(nodes, weightsPerNode int, r *rand.Rand) [][]float64 {
+	weights := make([][]float64, nodes)
+	for i := range weights {
+		weights[i] = make([]float64, weightsPerNode)
+		for j := range weights[i] {
+			weights[i][j] = r.NormFloat64()
+		}
+	}
+	return weights
+}
+
+func (n *Network) inputCount() int {
+	return len(networkConditions) + n.Flags + len(networkValues)
+}
+
+// actions returns the action chosen by each output
+func (n *Network) actions() []d.Action {
+	actions := append([]d.Action{}, d.Actions[:]...)
+	actions = append(actions, d.FlagSetActions[:n.Flags]...)
+	return append(actions, d.FlagClearActions[:n.Flags]...)
+}
+
+func (n *Network) inputNames() []string {
+	names := make([]string, 0, n.inputCount())
+	for _, condition := range networkConditions {
+		names = append(names, d.Map[condition])
+	}
+	for _, condition := range d.FlagConditions[:n.Flags] {
+		names = append(names, d.Map[condition])
+	}
+	return append(names, networkValues...)
+}
+
+func (n *Network) inputs(s Sensors, r *rand.Rand) []float64 {
+	inputs := make([]float64, 0, n.inputCount())
+	for _, condition := range networkConditions {
+		inputs = append(inputs, boolInput(s.IsConditionTrue(condition, 0, r)))
+	}
+	for _, condition := range d.FlagConditions[:n.Flags] {
+		inputs = append(inputs, boolInput(s.IsConditionTrue(condition, 0, r)))
+	}
+	return append(inputs, s.Health(), s.Ph(), s.FoodAhead(), r.Float64())
+}
+
+func boolInput(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// activate returns the output of each node given its weights, with the last
+// weight of each node applied to a constant bias input
+func activate(weights [][]float64, inputs []float64, f func(float64) float64) []float64 {
+	outputs := make([]float64, len(weights))
+	for i, nodeWeights := range weights {
+		sum := nodeWeights[len(inputs)]
+		for j, input := range inputs {
+			sum += nodeWeights[j] * input
+		}
+		outputs[i] = f(sum)
+	}
+	return outputs
+}
+
+func identity(x float64) float64 { return x }
+
+// Decide returns the action with the highest output for the current senses
+func (n *Network) Decide(s Sensors, r *rand.Rand) d.Action {
+	hidden := activate(n.Hidden, n.inputs(s, r), math.Tanh)
+	outputs := activate(n.Output, hidden, identity)
+	best := 0
+	for i, output := range outputs {
+		if output > outputs[best] {
+			best = i
+		}
+	}
+	return n.actions()[best]
+}
+
+// Copy returns a Network with the same weights
+func (n *Network) Copy() Brain {
+	return n.copyNetwork()
+}
+
+func (n *Network) copyNetwork() *Network {
+	return &Network{
+		Flags:  n.Flags,
+		Hidden: copyWeights(n.Hidden),
+		Output: copyWeights(n.Output),
+	}
+}
+
+func copyWeights(weights [][]float64) [][]float64 {
+	copied := make([][]float64, len(weights))
+	for i := range weights {
+		copied[i] = append([]float64{}, weights[i]...)
+	}
+	return copied
+}
+
+// Mutate returns a copy of the Network where each weight has a
+// NetworkMutationRate chance of changing by a normally-distributed amount
+// with a standard deviation of NetworkMutationSize
+func (n *Network) Mutate(g *config.Globals, r *rand.Rand) Brain {
+	mutated := n.copyNetwork()
+	for _, layer := range [][][]float64{mutated.Hidden, mutated.Output} {
+		for _, nodeWeights := range layer {
+			for i := range nodeWeights {
+				if r.Float64() < g.NetworkMutationRate {
+					nodeWeights[i] += r.NormFloat64() * g.NetworkMutationSize
+				}
+			}
+		}
+	}
+	return mutated
+}
+
+// Crossover returns a new Network taking the weights into each node from
+// either this Network or its mate at random. Networks with different
+// topologies can't be combined, so a copy of this one is returned instead.
+func (n *Network) Crossover(mate *Network, r *rand.Rand) *Network {
+	child := n.copyNetwork()
+	if mate.Flags != n.Flags || len(mate.Hidden) != len(n.Hidden) {
+		return child
+	}
+	for _, layers := range [][2][][]float64{{child.Hidden, mate.Hidden}, {child.Output, mate.Output}} {
+		for i := range layers[0] {
+			if r.Intn(2) == 0 {
+				layers[0][i] = append([]float64{}, layers[1][i]...)
+			}
+		}
+	}
+	return child
+}
+
+// Equal returns true if the other Brain is a Network with the same topology
+// and weights
+func (n *Network) Equal(other Brain) bool {
+	network, ok := other.(*Network)
+	return ok && network.Flags == n.Flags &&
+		equalWeights(network.Hidden, n.Hidden) && equalWeights(network.Output, n.Output)
+}
+
+func equalWeights(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check returns an error if the Network's weights don't fit its topology, as
+// when it's read from a file
+func (n *Network) Check() error {
+	if n.Flags < 0 || n.Flags > d.MaxMemoryFlags {
+		return fmt.Errorf("network has %d memory flags, expected 0 to %d", n.Flags, d.MaxMemoryFlags)
+	}
+	if len(n.Hidden) < 1 {
+		return fmt.Errorf("network has no hidden nodes")
+	}
+	if len(n.Output) != len(n.actions()) {
+		return fmt.Errorf("network has %d outputs, expected %d", len(n.Output), len(n.actions()))
+	}
+	for _, layer := range []struct {
+		weights [][]float64
+		inputs  int
+	}{{n.Hidden, n.inputCount()}, {n.Output, len(n.Hidden)}} {
+		for i, nodeWeights := range layer.weights {
+			if len(nodeWeights) != layer.inputs+1 {
+				return fmt.Errorf("network node %d has %d weights, expected %d", i, len(nodeWeights), layer.inputs+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Size returns the number of hidden and output nodes in the Network
+func (n *Network) Size() int {
+	return len(n.Hidden) + len(n.Output)
+}
+
+// describedWeights is the number of strongest weights listed for each node
+// by Describe
+const describedWeights = 2
+
+// Describe lists each hidden node and output with the inputs it's most
+// strongly connected to
+func (n *Network) Describe() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Network %d-%d-%d\n", n.inputCount(), len(n.Hidden), len(n.Output)))
+
+	hiddenNames := make([]string, len(n.Hidden))
+	for i := range n.Hidden {
+		hiddenNames[i] = fmt.Sprintf("H%d", i+1)
+	}
+	for i, nodeWeights := range n.Hidden {
+		builder.WriteString(describeNode(hiddenNames[i], nodeWeights, n.inputNames()))
+	}
+	for i, action := range n.actions() {
+		builder.WriteString(describeNode(d.Map[action], n.Output[i], hiddenNames))
+	}
+	return builder.String()
+}
+
+// describeNode returns a line naming a node and its strongest weighted
+// inputs, ignoring its bias
+func describeNode(name string, weights []float64, inputNames []string) string {
+	order := make([]int, len(inputNames))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return math.Abs(weights[order[a]]) > math.Abs(weights[order[b]])
+	})
+	parts := make([]string, 0, describedWeights)
+	for _, i := range order {
+		if len(parts) == describedWeights {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%+.2f %s", weights[i], inputNames[i]))
+	}
+	return fmt.Sprintf("%s: %s\n", name, strings.Join(parts, ", "))
+}
@@ -0,0 +1,144 @@
+package brain
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+)
+
+// testSensors senses the same fixed values every time
+type testSensors struct {
+	conditions            map[d.Condition]bool
+	health, ph, foodAhead float64
+}
+
+func (s testSensors) IsConditionTrue(condition d.Condition, _ float64, _ *rand.Rand) bool {
+	return s.conditions[condition]
+}
+
+func (s testSensors) Health() float64    { return s.health }
+func (s testSensors) Ph() float64        { return s.ph }
+func (s testSensors) FoodAhead() float64 { return s.foodAhead }
+
+func TestNetworkDecide(t *testing.T) {
+	globals := &config.Globals{MemoryFlags: 1, NetworkHiddenNodes: 1}
+	network := NewNetwork(globals, rand.New(rand.NewSource(1)))
+	assert.Equal(t, len(d.Actions)+2, len(network.Output))
+	assert.Equal(t, len(network.Output)+1, network.Size())
+
+	// a single hidden node passing on whether there's food ahead, with only
+	// Eat and Move Ahead connected to it
+	for i := range network.Hidden[0] {
+		network.Hidden[0][i] = 0
+	}
+	network.Hidden[0][1] = 5
+	network.Hidden[0][len(network.Hidden[0])-1] = -2.5
+	for i, action := range network.actions() {
+		network.Output[i] = []float64{0, -1}
+		switch action {
+		case d.ActEat:
+			network.Output[i] = []float64{1, 0}
+		case d.ActMove:
+			network.Output[i] = []float64{-1, 0}
+		}
+	}
+
+	r := rand.New(rand.NewSource(2))
+	foodAhead := testSensors{conditions: map[d.Condition]bool{d.IsFoodAhead: true}}
+	assert.Equal(t, d.ActEat, network.Decide(foodAhead, r))
+	assert.Equal(t, d.ActMove, network.Decide(testSensors{}, r))
+}
+
+func TestNetworkMutate(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	network := NewNetwork(&config.Globals{NetworkHiddenNodes: 4}, r)
+	original := network.copyNetwork()
+
+	unchanged := network.Mutate(&config.Globals{NetworkMutationRate: 0, NetworkMutationSize: 1}, r)
+	assert.Equal(t, original, unchanged)
+	assert.True(t, network.Equal(unchanged))
+
+	mutated := network.Mutate(&config.Globals{NetworkMutationRate: 1, NetworkMutationSize: 1}, r).(*Network)
+	assert.Equal(t, original, network, "mutating must not change the original")
+	assert.NotEqual(t, original.Hidden, mutated.Hidden)
+	assert.NotEqual(t, original.Output, mutated.Output)
+	assert.False(t, network.Equal(mutated))
+	assert.False(t, network.Equal(FromTree(d.TreeFromAction(d.ActEat))))
+	assert.Equal(t, network.Size(), mutated.Size())
+}
+
+func TestNetworkCrossover(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	globals := &config.Globals{NetworkHiddenNodes: 8}
+	first, second := NewNetwork(globals, r), NewNetwork(globals, r)
+	child := first.Crossover(second, r)
+
+	fromFirst, fromSecond := 0, 0
+	for i := range child.Hidden {
+		switch {
+		case assert.ObjectsAreEqual(child.Hidden[i], first.Hidden[i]):
+			fromFirst++
+		case assert.ObjectsAreEqual(child.Hidden[i], second.Hidden[i]):
+			fromSecond++
+		default:
+			t.Errorf("hidden node %d came from neither parent", i)
+		}
+	}
+	assert.Greater(t, fromFirst, 0)
+	assert.Greater(t, fromSecond, 0)
+
+	// networks with different topologies can't be combined
+	other := NewNetwork(&config.Globals{NetworkHiddenNodes: 3}, r)
+	assert.Equal(t, first, first.Crossover(other, r))
+}
+
+func TestDescribe(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	network := NewNetwork(&config.Globals{NetworkHiddenNodes: 3}, r)
+	lines := strings.Split(strings.TrimSuffix(network.Describe(), "\n"), "\n")
+	assert.Len(t, lines, 1+network.Size())
+	assert.Equal(t, "Network 16-3-7", lines[0])
+
+	tree := FromTree(d.TreeFromAction(d.ActEat))
+	assert.Equal(t, "Eat\n", tree.Describe())
+	assert.Equal(t, 1, tree.Size())
+}
+
+func TestNew(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	globals := &config.Globals{MaxDecisionTreeSize: 32, InitialDecisionTreeMutations: 3, NetworkHiddenNodes: 2}
+
+	globals.BrainType = config.BrainTree
+	assert.IsType(t, &Tree{}, New(globals, r))
+	globals.BrainType = config.BrainNetwork
+	assert.IsType(t, &Network{}, New(globals, r))
+
+	globals.BrainType = config.BrainMixed
+	trees := 0
+	for i := 0; i < 100; i++ {
+		if _, ok := New(globals, r).(*Tree); ok {
+			trees++
+		}
+	}
+	assert.Greater(t, trees, 20)
+	assert.Less(t, trees, 80)
+}
+
+func TestNetworkCheck(t *testing.T) {
+	globals := &config.Globals{MemoryFlags: 1, NetworkHiddenNodes: 2}
+	network := NewNetwork(globals, rand.New(rand.NewSource(1)))
+	assert.NoError(t, network.Check())
+
+	network.Flags = 0
+	assert.Error(t, network.Check())
+	network.Flags = 1
+	network.Hidden[1] = network.Hidden[1][1:]
+	assert.Error(t, network.Check())
+	network.Hidden = nil
+	assert.Error(t, network.Check())
+}
@@ -0,0 +1,142 @@
+package brain
+
+import (
+	"math/rand"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+)
+
+// Tree is a Brain that decides using a decision tree, compiled to a Program
+// when the Tree is created so it can be run quickly every cycle. The decision
+// tree and its Program never change, so copies of a Tree share them (as do
+// all Trees with the same ID, once added to a Pool), and only a mutation
+// creates new ones. Each Tree keeps its own visit counts and last path in a
+// decision.Trace.
+type Tree struct {
+	*d.Tree
+	program *d.Program
+	trace   *d.Trace
+}
+
+// FromTree returns a Tree using the given decision tree, with no visits yet.
+// The decision tree must not be changed afterward.
+func FromTree(tree *d.Tree) *Tree {
+	return FromTrace(tree, d.NewTrace(tree.Node))
+}
+
+// FromTrace returns a Tree using the given decision tree, continuing from the
+// visits and last path of an earlier Tree using it (see Trace)
+func FromTrace(tree *d.Tree, trace *d.Trace) *Tree {
+	return &Tree{Tree: tree, program: d.Compile(tree.Node), trace: trace}
+}
+
+// NewTree returns a Tree with a single random action, mutated
+// InitialDecisionTreeMutations times
+func NewTree(g *config.Globals, r *rand.Rand) *Tree {
+	tree := d.TreeFromAction(d.GetRandomAction(g, r))
+	for mutations := 0; mutations < g.InitialDecisionTreeMutations; mutations++ {
+		tree = d.MutateTree(tree, g, r)
+	}
+	return FromTree(tree)
+}
+
+// Decide runs the compiled decision tree, recording the path it takes
+func (t *Tree) Decide(s Sensors, r *rand.Rand) d.Action {
+	return t.program.Run(s, r, t.trace)
+}
+
+// Path returns the indexes of the nodes visited by the last decision, in the
+// order they're printed
+func (t *Tree) Path() []int {
+	return t.trace.Path
+}
+
+// Trace returns a copy of the Tree's visit counts and last path
+func (t *Tree) Trace() *d.Trace {
+	return t.trace.Copy()
+}
+
+// ResetVisits sets the Tree's visit counts to 0
+func (t *Tree) ResetVisits() {
+	t.trace.ResetVisits()
+}
+
+// Copy returns a Tree sharing the same decision tree, with a copy of its
+// visit counts
+func (t *Tree) Copy() Brain {
+	return t.copyTree()
+}
+
+func (t *Tree) copyTree() *Tree {
+	return &Tree{Tree: t.Tree, program: t.program, trace: t.trace.Copy()}
+}
+
+// Mutate returns a Tree with a mutated copy of the decision tree (see
+// decision.MutateTree), or sharing the same one if the mutations cancelled
+// out
+func (t *Tree) Mutate(g *config.Globals, r *rand.Rand) Brain {
+	mutated := d.MutateTree(t.Tree, g, r)
+	if mutated.ID == t.ID {
+		return t.copyTree()
+	}
+	return FromTree(mutated)
+}
+
+// Equal returns true if the other Brain is a Tree with the same decision
+// tree
+func (t *Tree) Equal(other Brain) bool {
+	tree, ok := other.(*Tree)
+	return ok && tree.ID == t.ID
+}
+
+// Size returns the number of nodes in the decision tree
+func (t *Tree) Size() int {
+	return t.Tree.Size()
+}
+
+// Describe returns the printed decision tree, with the nodes visited by the
+// last decision marked
+func (t *Tree) Describe() string {
+	return t.PrintPath(t.trace.Path)
+}
+
+// shared is a decision tree and its Program, shared by the Trees in a Pool
+type shared struct {
+	tree    *d.Tree
+	program *d.Program
+	users   int
+}
+
+// Pool holds a single copy of each distinct decision tree in use, keyed by
+// ID, so identical trees created separately (eg. by different mutations) are
+// only kept once
+type Pool map[string]*shared
+
+// NewPool returns an empty Pool
+func NewPool() Pool {
+	return make(Pool)
+}
+
+// Share switches the Tree to the pool's copy of its decision tree and
+// Program, adding them to the pool if they're the first with their ID
+func (p Pool) Share(t *Tree) {
+	entry, ok := p[t.ID]
+	if !ok {
+		entry = &shared{tree: t.Tree, program: t.program}
+		p[t.ID] = entry
+	}
+	t.Tree, t.program = entry.tree, entry.program
+	entry.users++
+}
+
+// Release removes a Tree's decision tree from the pool once no Trees sharing
+// it are left
+func (p Pool) Release(t *Tree) {
+	if entry, ok := p[t.ID]; ok {
+		entry.users--
+		if entry.users <= 0 {
+			delete(p, t.ID)
+		}
+	}
+}
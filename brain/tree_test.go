@@ -0,0 +1,70 @@
+package brain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	d "github.com/Zebbeni/protozoa/decision"
+)
+
+func parseTree(t *testing.T, text string) *Tree {
+	tree, err := d.ParseTree(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FromTree(tree)
+}
+
+func TestTreeCopiesShareNodes(t *testing.T) {
+	tree := parseTree(t, "(IfFoodAhead Eat TurnLeft)")
+	foodAhead := testSensors{conditions: map[d.Condition]bool{d.IsFoodAhead: true}}
+	assert.Equal(t, d.ActEat, tree.Decide(foodAhead, nil))
+
+	copied := tree.Copy().(*Tree)
+	assert.Same(t, tree.Tree, copied.Tree)
+	assert.True(t, tree.Equal(copied))
+	assert.True(t, tree.Equal(parseTree(t, "(IfFoodAhead Eat TurnLeft)")))
+	assert.False(t, tree.Equal(parseTree(t, "(IfFoodAhead Eat TurnRight)")))
+	assert.Equal(t, d.ActTurnLeft, copied.Decide(testSensors{}, nil))
+
+	// each copy keeps its own visits, and describes its own last decision
+	assert.Equal(t, []int{0, 1}, tree.Path())
+	assert.Equal(t, []int{0, 2}, copied.Path())
+	assert.Equal(t, []int{1, 1, 0}, tree.Trace().Visits)
+	assert.Equal(t, []int{2, 1, 1}, copied.Trace().Visits)
+	assert.Equal(t, "If Food Ahead ◀◀\n├─Eat ◀◀\n└─Turn Left\n", tree.Describe())
+	assert.Equal(t, "If Food Ahead ◀◀\n├─Eat\n└─Turn Left ◀◀\n", copied.Describe())
+	assert.NotContains(t, tree.Print(), "◀◀")
+
+	copied.ResetVisits()
+	assert.Equal(t, []int{0, 0, 0}, copied.Trace().Visits)
+	assert.Equal(t, []int{1, 1, 0}, tree.Trace().Visits)
+
+	// a Tree restored from a trace continues from its visits
+	restored := FromTrace(tree.Tree, tree.Trace())
+	assert.Equal(t, tree.Describe(), restored.Describe())
+	assert.Equal(t, []int{1, 1, 0}, restored.Trace().Visits)
+}
+
+func TestPool(t *testing.T) {
+	pool := NewPool()
+	first := parseTree(t, "(IfFoodAhead Eat TurnLeft)")
+	second := parseTree(t, "(IfFoodAhead Eat TurnLeft)")
+	other := parseTree(t, "(IfFoodAhead Eat TurnRight)")
+	assert.NotSame(t, first.Tree, second.Tree)
+
+	pool.Share(first)
+	pool.Share(second)
+	pool.Share(other)
+	assert.Same(t, first.Tree, second.Tree)
+	assert.NotSame(t, first.Tree, other.Tree)
+	assert.Len(t, pool, 2)
+
+	// trees stay in the pool until every tree sharing them is released
+	pool.Release(first)
+	assert.Len(t, pool, 2)
+	pool.Release(second)
+	pool.Release(other)
+	assert.Empty(t, pool)
+}
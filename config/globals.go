@@ -2,67 +2,99 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 )
 
 var defaultFilePath = "settings/default.json"
-var constants *Globals
 
-// SetGlobals allows a one-time initialization of all globally-referenced constants
-func SetGlobals(g *Globals) {
-	if constants != nil {
-		return
-	}
-	constants = g
-}
+// Action resolution modes
+const (
+	// ResolveOrdered applies each organism's action in turn, in update order
+	ResolveOrdered = "ordered"
+	// ResolveSimultaneous collects all actions first, then settles conflicts
+	// between them using the configured ConflictRule
+	ResolveSimultaneous = "simultaneous"
+)
+
+// Conflict rules used to settle competing actions when resolving simultaneously
+const (
+	// ConflictRandom picks a random winner
+	ConflictRandom = "random"
+	// ConflictBigger picks the biggest organism (random among ties)
+	ConflictBigger = "bigger"
+	// ConflictSplit shares contested food equally, and picks a random winner
+	// for anything that can't be shared (eg. an empty grid location)
+	ConflictSplit = "split"
+)
+
+// End conditions, any of which can be listed in EndConditions to end a run
+const (
+	// EndMaxOrganisms ends a run once the population reaches MaxOrganisms
+	EndMaxOrganisms = "max_organisms"
+	// EndMaxCycles ends a run after MaxCycles cycles
+	EndMaxCycles = "max_cycles"
+	// EndExtinction ends a run once no organisms are left alive
+	EndExtinction = "extinction"
+	// EndMinOrganisms ends a run once the population falls below MinOrganisms
+	EndMinOrganisms = "min_organisms"
+	// EndSingleLineage ends a run once all living organisms descend from a
+	// single original ancestor
+	EndSingleLineage = "single_lineage"
+	// EndTimeout ends a run after MaxRunSeconds of wall-clock time
+	EndTimeout = "timeout"
+	// EndStagnation ends a run after StagnationCycles cycles without any
+	// new decision tree appearing in the population
+	EndStagnation = "stagnation"
+)
 
-func GridUnitSize() int                        { return constants.GridUnitSize }
-func GridWidth() int                           { return constants.GridWidth }
-func GridHeight() int                          { return constants.GridHeight }
-func GridUnitsWide() int                       { return constants.GridUnitsWide }
-func GridUnitsHigh() int                       { return constants.GridUnitsHigh }
-func ScreenWidth() int                         { return constants.ScreenWidth }
-func ScreenHeight() int                        { return constants.ScreenHeight }
-func PopulationUpdateInterval() int            { return constants.PopulationUpdateInterval }
-func InitialOrganisms() int                    { return constants.InitialOrganisms }
-func InitialFood() int                         { return constants.InitialFood }
-func ChanceToAddFoodItem() float64             { return constants.ChanceToAddFoodItem }
-func MaxFoodValue() int                        { return constants.MaxFoodValue }
-func MinFoodValue() int                        { return constants.MinFoodValue }
-func MinPh() float64                           { return constants.MinPh }
-func MaxPh() float64                           { return constants.MaxPh }
-func MinInitialPh() float64                    { return constants.MinInitialPh }
-func MaxInitialPh() float64                    { return constants.MaxInitialPh }
-func MaxCyclesBetweenSpawns() int              { return constants.MaxCyclesBetweenSpawns }
-func MinSpawnHealth() float64                  { return constants.MinSpawnHealth }
-func MaxSpawnHealthPercent() float64           { return constants.MaxSpawnHealthPercent }
-func InitialDecisionTreeMutations() int        { return constants.InitialDecisionTreeMutations }
-func MinChanceToMutateDecisionTree() float64   { return constants.MinChanceToMutateDecisionTree }
-func MaxChanceToMutateDecisionTree() float64   { return constants.MaxChanceToMutateDecisionTree }
-func MinOrganisms() int                        { return constants.MinOrganisms }
-func MaxOrganisms() int                        { return constants.MaxOrganisms }
-func GrowthFactor() float64                    { return constants.GrowthFactor }
-func MaximumMaxSize() float64                  { return constants.MaximumMaxSize }
-func MinimumMaxSize() float64                  { return constants.MinimumMaxSize }
-func MinIdealPh() float64                      { return constants.MinIdealPh }
-func MaxIdealPh() float64                      { return constants.MaxIdealPh }
-func MinPhTolerance() float64                  { return constants.MinPhTolerance }
-func MaxPhTolerance() float64                  { return constants.MaxPhTolerance }
-func MaxOrganismPhEffect() float64             { return constants.MaxOrganismPhEffect }
-func PhIncrementToDisplay() float64            { return constants.PhIncrementToDisplay }
-func PhDiffuseFactor() float64                 { return constants.PhDiffuseFactor }
-func HealthChangeFromChemosynthesis() float64  { return constants.HealthChangeFromChemosynthesis }
-func HealthChangeFromTurning() float64         { return constants.HealthChangeFromTurning }
-func HealthChangeFromMoving() float64          { return constants.HealthChangeFromMoving }
-func HealthChangeFromEatingAttempt() float64   { return constants.HealthChangeFromEatingAttempt }
-func HealthChangeFromAttacking() float64       { return constants.HealthChangeFromAttacking }
-func HealthChangeInflictedByAttack() float64   { return constants.HealthChangeInflictedByAttack }
-func HealthChangeFromFeeding() float64         { return constants.HealthChangeFromFeeding }
-func HealthChangePerDecisionTreeNode() float64 { return constants.HealthChangePerDecisionTreeNode }
-func HealthChangePerUnhealthyPh() float64      { return constants.HealthChangePerCycleUnhealthyPh }
-func MaxDecisionTreeSize() int                 { return constants.MaxDecisionTreeSize }
+// Reseed strategies used to top the population back up to MinOrganisms
+const (
+	// ReseedNone never adds organisms to a shrinking population
+	ReseedNone = "none"
+	// ReseedRandom adds new organisms with random traits and decision trees
+	ReseedRandom = "random"
+	// ReseedSurvivor adds mutated clones of randomly-chosen living organisms
+	ReseedSurvivor = "survivor"
+	// ReseedArchive adds mutated clones of organisms from an archive of those
+	// that had the most children before dying
+	ReseedArchive = "archive"
+)
 
+// Reproduction modes
+const (
+	// ReproduceAsexual gives children mutated copies of their parent's traits
+	// and decision tree
+	ReproduceAsexual = "asexual"
+	// ReproduceCrossover gives children of a parent with a compatible
+	// neighbour a mix of both organisms' traits and decision trees
+	ReproduceCrossover = "crossover"
+)
+
+// Distributions of the number of operators applied per decision tree mutation
+const (
+	// MutationCountFixed always applies MutationCountMean operators (rounded)
+	MutationCountFixed = "fixed"
+	// MutationCountPoisson applies 1 operator plus a Poisson-distributed
+	// number more, for a mean of MutationCountMean
+	MutationCountPoisson = "poisson"
+	// MutationCountGeometric applies operators until one succeeds with chance
+	// 1 / MutationCountMean, for a mean of MutationCountMean
+	MutationCountGeometric = "geometric"
+)
+
+// Brain types used to decide organisms' actions
+const (
+	// BrainTree decides with an evolved decision tree
+	BrainTree = "tree"
+	// BrainNetwork decides with a small neural network with evolved weights
+	BrainNetwork = "network"
+	// BrainMixed gives each random organism either kind of brain
+	BrainMixed = "mixed"
+)
+
+// Globals contains all constants used to configure a simulation
 type Globals struct {
 	// Drawing parameters
 	GridUnitSize  int `json:"grid_unit_size"`
@@ -93,6 +125,12 @@ type Globals struct {
 	MaxSpawnHealthPercent         float64 `json:"max_spawn_health_percent"`
 	MinOrganisms                  int     `json:"min_organisms"`
 	MaxOrganisms                  int     `json:"max_organisms"`
+	ReseedStrategy                string  `json:"reseed_strategy"`
+	ReseedArchiveSize             int     `json:"reseed_archive_size"`
+	GenealogyRecordLimit          int     `json:"genealogy_record_limit"`
+	TreeLibraryEvictionCycles     int     `json:"tree_library_eviction_cycles"`
+	ReproductionMode              string  `json:"reproduction_mode"`
+	CrossoverRelatedOnly          bool    `json:"crossover_related_only"`
 	GrowthFactor                  float64 `json:"growth_factor"`
 	MaximumMaxSize                float64 `json:"maximum_max_size"`
 	MinimumMaxSize                float64 `json:"minimum_max_size"`
@@ -100,6 +138,7 @@ type Globals struct {
 	MinChanceToMutateDecisionTree float64 `json:"min_chance_to_mutate_decision_tree"`
 	MaxChanceToMutateDecisionTree float64 `json:"max_chance_to_mutate_decision_tree"`
 	MaxDecisionTreeSize           int     `json:"max_decision_tree_size"`
+	PruneMutationChance           float64 `json:"prune_mutation_chance"`
 	MinIdealPh                    float64 `json:"min_ideal_ph"`
 	MaxIdealPh                    float64 `json:"max_ideal_ph"`
 	MinPhTolerance                float64 `json:"min_ph_tolerance"`
@@ -110,6 +149,35 @@ type Globals struct {
 	PhIncrementToDisplay          float64 `json:"ph_increment_to_display"`
 	PhDiffuseFactor               float64 `json:"ph_diffuse_factor"`
 
+	// Number of memory flags organisms' decision trees can set, clear and
+	// check (up to 4)
+	MemoryFlags int `json:"memory_flags"`
+
+	// Brain parameters. BrainType selects the brain of random organisms, and
+	// children inherit their parent's. Each network weight has a
+	// NetworkMutationRate chance of changing per mutation, by a normally
+	// distributed amount with a standard deviation of NetworkMutationSize.
+	BrainType           string  `json:"brain_type"`
+	NetworkHiddenNodes  int     `json:"network_hidden_nodes"`
+	NetworkMutationRate float64 `json:"network_mutation_rate"`
+	NetworkMutationSize float64 `json:"network_mutation_size"`
+
+	// Decision tree mutation parameters. Each mutation operator is chosen
+	// with a chance relative to its weight. If no weights are set, a single
+	// node is either grown, collapsed or changed.
+	MutationWeightGrow            float64 `json:"mutation_weight_grow"`
+	MutationWeightChangeAction    float64 `json:"mutation_weight_change_action"`
+	MutationWeightCollapse        float64 `json:"mutation_weight_collapse"`
+	MutationWeightChangeCondition float64 `json:"mutation_weight_change_condition"`
+	MutationWeightNudge           float64 `json:"mutation_weight_nudge"`
+	MutationWeightRegrow          float64 `json:"mutation_weight_regrow"`
+	MutationWeightWrap            float64 `json:"mutation_weight_wrap"`
+	MutationWeightSwapBranches    float64 `json:"mutation_weight_swap_branches"`
+	MutationWeightDuplicate       float64 `json:"mutation_weight_duplicate"`
+	MutationWeightHoist           float64 `json:"mutation_weight_hoist"`
+	MutationCountDistribution     string  `json:"mutation_count_distribution"`
+	MutationCountMean             float64 `json:"mutation_count_mean"`
+
 	// Health parameters (percent of organism size)
 	HealthChangeFromChemosynthesis  float64 `json:"health_change_from_chemosynthesis"`
 	HealthChangeFromTurning         float64 `json:"health_change_from_turning"`
@@ -120,6 +188,56 @@ type Globals struct {
 	HealthChangeFromFeeding         float64 `json:"health_change_from_feeding"`
 	HealthChangePerDecisionTreeNode float64 `json:"health_change_per_decision_tree_node"`
 	HealthChangePerCycleUnhealthyPh float64 `json:"health_change_per_unhealthy_ph"`
+
+	// Action resolution parameters
+	ActionResolutionMode string `json:"action_resolution_mode"`
+	ConflictRule         string `json:"conflict_rule"`
+
+	// End condition parameters
+	EndConditions    []string `json:"end_conditions"`
+	MaxCycles        int      `json:"max_cycles"`
+	MaxRunSeconds    float64  `json:"max_run_seconds"`
+	StagnationCycles int      `json:"stagnation_cycles"`
+}
+
+// choice is a setting that takes one of a fixed set of names
+type choice struct {
+	setting, value string
+	allowed        []string
+}
+
+// choices lists the settings that take one of a fixed set of names
+func (g *Globals) choices() []choice {
+	return []choice{
+		{"action_resolution_mode", g.ActionResolutionMode, []string{ResolveOrdered, ResolveSimultaneous}},
+		{"conflict_rule", g.ConflictRule, []string{ConflictRandom, ConflictBigger, ConflictSplit}},
+		{"reseed_strategy", g.ReseedStrategy, []string{ReseedNone, ReseedRandom, ReseedSurvivor, ReseedArchive}},
+		{"reproduction_mode", g.ReproductionMode, []string{ReproduceAsexual, ReproduceCrossover}},
+		{"mutation_count_distribution", g.MutationCountDistribution,
+			[]string{MutationCountFixed, MutationCountPoisson, MutationCountGeometric}},
+		{"brain_type", g.BrainType, []string{BrainTree, BrainNetwork, BrainMixed}},
+	}
+}
+
+// Validate returns an error if any setting that takes one of a fixed set of
+// names (eg. conflict_rule) has a value that isn't one of them, since it
+// would otherwise silently fall back to a different behavior
+func (g *Globals) Validate() error {
+	for _, choice := range g.choices() {
+		if !contains(choice.allowed, choice.value) {
+			return fmt.Errorf("unknown %s %q (expected one of %v)", choice.setting, choice.value, choice.allowed)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func LoadFile(filePath string) io.Reader {
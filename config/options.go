@@ -1,6 +1,10 @@
 package config
 
-import "flag"
+import (
+	"flag"
+	"runtime"
+	"time"
+)
 
 type Options struct {
 	ConfigFile  string
@@ -9,6 +13,27 @@ type Options struct {
 	IsDebugging bool
 	TrialCount  int
 	Seed        int
+	Workers     int
+	SaveFile    string
+	LoadFile    string
+	SweepFile   string
+	SweepOutput string
+	MetricsFile string
+	TreesFile   string
+	TopTrees    int
+	BankOutput  string
+	NewickFile  string
+
+	// organisms to start with, alongside the random ones
+	PopulationFile string
+	BankFile       string
+	BankSelect     string
+	BankCount      int
+
+	// end condition overrides, applied on top of the config globals
+	EndConditions string
+	MaxCycles     int
+	Timeout       time.Duration
 }
 
 func GetOptions() *Options {
@@ -19,7 +44,24 @@ func GetOptions() *Options {
 	flag.BoolVar(&opts.IsHeadless, "headless", false, "Run simulation without visualization")
 	flag.IntVar(&opts.TrialCount, "trials", 1, "Number of trials to run")
 	flag.IntVar(&opts.Seed, "seed", 0, "Set the random seed")
-	flag.StringVar(&opts.ConfigFile, "config", "", "Config file in JSON format")
+	flag.IntVar(&opts.Workers, "workers", runtime.NumCPU(), "Number of workers used to choose organism actions each cycle")
+	flag.StringVar(&opts.ConfigFile, "config", "", "Config file in JSON format (eg. set reseed_strategy there to keep the population above min_organisms, which is off by default)")
+	flag.StringVar(&opts.PopulationFile, "population", "", "Population file in JSON format describing organisms to start with, alongside the random ones")
+	flag.StringVar(&opts.BankFile, "bank", "", "Genome bank file in JSON format to seed organisms from, alongside the random ones")
+	flag.StringVar(&opts.BankSelect, "bank-select", "all", "Genomes to seed from the -bank file: all, top (most children) or sample (at random)")
+	flag.IntVar(&opts.BankCount, "bank-count", 0, "Number of genomes seeded by -bank-select top or sample (0 for all)")
+	flag.StringVar(&opts.SaveFile, "save", "", "File to save the simulation state to when the run ends")
+	flag.StringVar(&opts.LoadFile, "load", "", "Simulation state file to resume from (replaces -config)")
+	flag.StringVar(&opts.MetricsFile, "metrics", "", "File to write metrics to every population update interval (.csv or .jsonl)")
+	flag.StringVar(&opts.TreesFile, "trees", "", "File to write the most common decision trees to when the run ends (.dot or .json)")
+	flag.IntVar(&opts.TopTrees, "top-trees", 10, "Number of decision trees written to the -trees file")
+	flag.StringVar(&opts.BankOutput, "bank-out", "", "File to write the genomes of all living organisms to when the run ends, for use with -bank")
+	flag.StringVar(&opts.NewickFile, "newick", "", "File to write the surviving tree of life to in Newick format when the run ends")
+	flag.StringVar(&opts.EndConditions, "end", "", "Comma-separated end conditions, replacing the config's end_conditions")
+	flag.IntVar(&opts.MaxCycles, "max-cycles", 0, "End the run after this many cycles")
+	flag.DurationVar(&opts.Timeout, "timeout", 0, "End the run after this much wall-clock time (eg. 10m)")
+	flag.StringVar(&opts.SweepFile, "sweep", "", "Parameter sweep spec in JSON format to run headless against -config")
+	flag.StringVar(&opts.SweepOutput, "sweep-out", "sweep.csv", "File to write parameter sweep results to in CSV format")
 
 	flag.Parse()
 
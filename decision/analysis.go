@@ -0,0 +1,203 @@
+package decision
+
+// test is a Condition with the parameter it's checked against, if any
+type test struct {
+	condition Condition
+	param     float64
+}
+
+// testOf returns the test a Condition node makes. IsHealthAboveFiftyPercent
+// is the same test as IsHealthAbove with a parameter of 0.5.
+func testOf(n *Node) test {
+	condition := n.NodeType.(Condition)
+	if condition == IsHealthAboveFiftyPercent {
+		return test{IsHealthAbove, 0.5}
+	}
+	return test{condition, n.Param}
+}
+
+// literal is a test known to be either true or false
+type literal struct {
+	test
+	value bool
+}
+
+// implication means that if all of its premises hold, so does its conclusion
+type implication struct {
+	premises   []literal
+	conclusion literal
+}
+
+// implications lists everything known about how conditions relate to each
+// other within a single cycle. An organism can move ahead only if there's no
+// food or organism there. Food can be placed under an organism, so seeing one
+// says nothing about the other.
+var implications = withContrapositives([]implication{
+	{[]literal{{test{IsBiggerOrganismAhead, 0}, true}}, literal{test{IsOrganismAhead, 0}, true}},
+	{[]literal{{test{IsRelatedOrganismAhead, 0}, true}}, literal{test{IsOrganismAhead, 0}, true}},
+	{[]literal{{test{IsOrganismAhead, 0}, true}}, literal{test{CanMove, 0}, false}},
+	{[]literal{{test{IsFoodAhead, 0}, true}}, literal{test{CanMove, 0}, false}},
+	{[]literal{{test{IsOrganismAhead, 0}, false}, {test{IsFoodAhead, 0}, false}}, literal{test{CanMove, 0}, true}},
+	{[]literal{{test{IsFoodValueAheadAbove, 0}, true}}, literal{test{IsFoodAhead, 0}, true}},
+	{[]literal{{test{IsRelatedOrganismLeft, 0}, true}}, literal{test{IsOrganismLeft, 0}, true}},
+	{[]literal{{test{IsRelatedOrganismRight, 0}, true}}, literal{test{IsOrganismRight, 0}, true}},
+})
+
+// withContrapositives adds the contrapositive of every single-premise
+// implication (if A then B, so if not B then not A)
+func withContrapositives(rules []implication) []implication {
+	all := append([]implication{}, rules...)
+	for _, rule := range rules {
+		if len(rule.premises) != 1 {
+			continue
+		}
+		premise := rule.premises[0]
+		all = append(all, implication{
+			premises:   []literal{{rule.conclusion.test, !rule.conclusion.value}},
+			conclusion: literal{premise.test, !premise.value},
+		})
+	}
+	return all
+}
+
+// isDeterministic returns true if a condition always has the same value when
+// checked more than once in a cycle
+func isDeterministic(condition Condition) bool {
+	return condition != IsRandomFiftyPercent && condition != IsRandomBelow
+}
+
+// isThreshold returns true if a condition is true when some value is above
+// its parameter, so being above one parameter means being above any lower one
+func isThreshold(condition Condition) bool {
+	switch condition {
+	case IsHealthAbove, IsPhAbove, IsFoodValueAheadAbove:
+		return true
+	}
+	return false
+}
+
+// facts are the values of tests known along a path through a tree
+type facts map[test]bool
+
+// value returns the value of a test, and whether it's known. The value of a
+// threshold test is also known from the same condition with a higher
+// parameter being true, or a lower one being false.
+func (f facts) value(t test) (value, known bool) {
+	if value, known = f[t]; known || !isThreshold(t.condition) {
+		return value, known
+	}
+	for other, otherValue := range f {
+		if other.condition != t.condition {
+			continue
+		}
+		if otherValue && other.param >= t.param {
+			return true, true
+		}
+		if !otherValue && other.param <= t.param {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// with returns a copy of the facts including a new literal and everything it
+// implies, and whether the result is consistent. Inconsistent facts mean the
+// path can never be taken.
+func (f facts) with(l literal) (facts, bool) {
+	result := make(facts, len(f)+1)
+	for t, value := range f {
+		result[t] = value
+	}
+	pending := []literal{l}
+	for len(pending) > 0 {
+		next := pending[0]
+		pending = pending[1:]
+		if value, known := result.value(next.test); known {
+			if value != next.value {
+				return result, false
+			}
+			continue
+		}
+		result[next.test] = next.value
+		for _, rule := range implications {
+			if result.holds(rule.premises) {
+				pending = append(pending, rule.conclusion)
+			}
+		}
+	}
+	return result, true
+}
+
+func (f facts) holds(literals []literal) bool {
+	for _, l := range literals {
+		if value, known := f.value(l.test); !known || value != l.value {
+			return false
+		}
+	}
+	return true
+}
+
+// Analysis describes how much of a decision tree can be removed without
+// changing the organism's behavior
+type Analysis struct {
+	Size                int // number of nodes in the tree
+	SimplifiedSize      int // number of nodes in the simplified tree
+	UnreachableNodes    int // nodes that can never be visited
+	RedundantConditions int // reachable conditions that never change the chosen action
+}
+
+// Analyze finds unreachable nodes and redundant conditions in the tree
+func (t *Tree) Analyze() Analysis {
+	analysis := Analysis{Size: len(t.getNodes())}
+	simplified := simplify(t.Node, facts{}, &analysis)
+	analysis.SimplifiedSize = len(simplified.getNodes())
+	return analysis
+}
+
+// Simplify returns a new, minimal tree that always chooses the same action as
+// this one. Conditions whose result is already known from conditions checked
+// before them are removed along with their unreachable branch, and so are
+// conditions whose branches are the same.
+func (t *Tree) Simplify() *Tree {
+	simplified := &Tree{Node: simplify(t.Node, facts{}, &Analysis{})}
+	simplified.size = simplified.CalcAndUpdateSize()
+	simplified.ID = simplified.Serialize()
+	return simplified
+}
+
+// simplify returns a simplified copy of a node, given the facts known on the
+// path to it, and adds anything removed to the analysis
+func simplify(n *Node, known facts, analysis *Analysis) *Node {
+	if n.IsAction() {
+		return NodeFromAction(n.NodeType.(Action))
+	}
+
+	condition := n.NodeType.(Condition)
+	yesFacts, noFacts := known, known
+	yesReachable, noReachable := true, true
+	if isDeterministic(condition) {
+		yesFacts, yesReachable = known.with(literal{testOf(n), true})
+		noFacts, noReachable = known.with(literal{testOf(n), false})
+	}
+
+	switch {
+	case !yesReachable:
+		analysis.RedundantConditions++
+		analysis.UnreachableNodes += len(n.YesNode.getNodes())
+		return simplify(n.NoNode, noFacts, analysis)
+	case !noReachable:
+		analysis.RedundantConditions++
+		analysis.UnreachableNodes += len(n.NoNode.getNodes())
+		return simplify(n.YesNode, yesFacts, analysis)
+	}
+
+	yes := simplify(n.YesNode, yesFacts, analysis)
+	no := simplify(n.NoNode, noFacts, analysis)
+	if yes.Serialize() == no.Serialize() {
+		analysis.RedundantConditions++
+		return yes
+	}
+	simplified := &Node{NodeType: condition, Param: n.Param, YesNode: yes, NoNode: no}
+	simplified.CalcAndUpdateSize()
+	return simplified
+}
@@ -0,0 +1,147 @@
+package decision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+func TestSimplify(t *testing.T) {
+	testCases := []struct {
+		tree, expected         string
+		unreachable, redundant int
+	}{
+		{"Eat", "Eat", 0, 0},
+		{"(IfFoodAhead Eat MoveAhead)", "(IfFoodAhead Eat MoveAhead)", 0, 0},
+		// same condition nested in its own branch
+		{"(IfFoodAhead (IfFoodAhead Eat Attack) MoveAhead)", "(IfFoodAhead Eat MoveAhead)", 1, 1},
+		// both branches the same
+		{"(IfFoodAhead TurnLeft TurnLeft)", "TurnLeft", 0, 1},
+		// a bigger organism ahead means there's an organism ahead, so we can't move
+		{"(IfBiggerOrganismAhead (IfCanMoveAhead MoveAhead Attack) Eat)", "(IfBiggerOrganismAhead Attack Eat)", 1, 1},
+		{"(IfCanMoveAhead (IfOrganismAhead Attack MoveAhead) Eat)", "(IfCanMoveAhead MoveAhead Eat)", 1, 1},
+		// food can lie under an organism
+		{"(IfFoodAhead (IfOrganismAhead Attack Eat) MoveAhead)", "(IfFoodAhead (IfOrganismAhead Attack Eat) MoveAhead)", 0, 0},
+		{"(IfFoodLeft (IfOrganismLeft TurnRight TurnLeft) Eat)", "(IfFoodLeft (IfOrganismLeft TurnRight TurnLeft) Eat)", 0, 0},
+		// with no food or organism ahead, we can always move
+		{"(IfFoodAhead Eat (IfOrganismAhead Attack (IfCanMoveAhead MoveAhead TurnLeft)))",
+			"(IfFoodAhead Eat (IfOrganismAhead Attack MoveAhead))", 1, 1},
+		// redundancy found after simplifying branches
+		{"(IsHealthyPhHere (IfFoodAhead Eat Eat) Eat)", "Eat", 0, 2},
+		// random conditions may change each time they're checked
+		{"(IsRandomFiftyPercent (IsRandomFiftyPercent Eat MoveAhead) TurnLeft)",
+			"(IsRandomFiftyPercent (IsRandomFiftyPercent Eat MoveAhead) TurnLeft)", 0, 0},
+		{"(IfRandomBelow:0.3 (IfRandomBelow:0.3 Eat MoveAhead) TurnLeft)",
+			"(IfRandomBelow:0.3 (IfRandomBelow:0.3 Eat MoveAhead) TurnLeft)", 0, 0},
+		// health above a threshold is above any lower one, and below any higher
+		{"(IfHealthAbove:0.6 (IfHealthAbove:0.4 Eat Attack) MoveAhead)", "(IfHealthAbove:0.6 Eat MoveAhead)", 1, 1},
+		{"(IfHealthAbove:0.6 Eat (IfHealthAbove:0.8 Attack MoveAhead))", "(IfHealthAbove:0.6 Eat MoveAhead)", 1, 1},
+		{"(IfHealthAbove:0.6 (IfHealthAbove:0.8 Eat Attack) MoveAhead)",
+			"(IfHealthAbove:0.6 (IfHealthAbove:0.8 Eat Attack) MoveAhead)", 0, 0},
+		{"(IsHealthAboveFiftyPercent (IfHealthAbove:0.5 Eat Attack) MoveAhead)", "(IsHealthAboveFiftyPercent Eat MoveAhead)", 1, 1},
+		// food of any value ahead means there's food ahead
+		{"(IfFoodValueAheadAbove:0.2 (IfFoodAhead Eat Attack) MoveAhead)", "(IfFoodValueAheadAbove:0.2 Eat MoveAhead)", 1, 1},
+		{"(IfFoodAhead Eat (IfFoodValueAheadAbove:0.2 Attack MoveAhead))", "(IfFoodAhead Eat MoveAhead)", 1, 1},
+	}
+
+	for _, tc := range testCases {
+		tree, err := ParseTree(tc.tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		simplified := tree.Simplify()
+		assert.Equal(t, tc.expected, simplified.String(), tc.tree)
+		assert.Equal(t, simplified.Serialize(), simplified.ID, tc.tree)
+		assert.Equal(t, len(simplified.getNodes()), simplified.Size(), tc.tree)
+
+		analysis := tree.Analyze()
+		assert.Equal(t, tc.unreachable, analysis.UnreachableNodes, tc.tree)
+		assert.Equal(t, tc.redundant, analysis.RedundantConditions, tc.tree)
+		assert.Equal(t, tree.Size(), analysis.Size, tc.tree)
+		assert.Equal(t, simplified.Size(), analysis.SimplifiedSize, tc.tree)
+	}
+}
+
+// TestSimplifyIsEquivalent checks that random trees and their simplified
+// versions choose the same action for every consistent combination of
+// condition values
+func TestSimplifyIsEquivalent(t *testing.T) {
+	globals := config.Globals{MaxDecisionTreeSize: 32}
+	r := rand.New(rand.NewSource(6))
+	for i := 0; i < 200; i++ {
+		tree := TreeFromAction(GetRandomAction(&globals, r))
+		for mutations := 0; mutations < 30; mutations++ {
+			tree = MutateTree(tree, &globals, r)
+		}
+		simplified := tree.Simplify()
+		assert.LessOrEqual(t, simplified.Size(), tree.Size())
+
+		for trial := 0; trial < 50; trial++ {
+			w := randomWorld(r)
+			assert.Equal(t, evaluate(tree.Node, w), evaluate(simplified.Node, w), tree.String())
+		}
+	}
+}
+
+// world holds the values of every condition in a single cycle
+type world struct {
+	values                facts // conditions without parameters
+	health, ph, foodValue float64
+}
+
+func randomWorld(r *rand.Rand) world {
+	w := world{
+		values: randomConsistentValues(r),
+		health: float64(r.Intn(101)) / 100,
+		ph:     float64(r.Intn(101)) / 10,
+	}
+	if w.values[test{IsFoodAhead, 0}] {
+		w.foodValue = float64(1 + r.Intn(100))
+	}
+	return w
+}
+
+func randomConsistentValues(r *rand.Rand) facts {
+	for {
+		values := facts{}
+		consistent := true
+		for _, condition := range Conditions {
+			if _, hasParam := Parameters[condition]; hasParam || condition == IsHealthAboveFiftyPercent {
+				continue
+			}
+			if _, known := values[test{condition, 0}]; known {
+				continue
+			}
+			if values, consistent = values.with(literal{test{condition, 0}, r.Intn(2) == 0}); !consistent {
+				break
+			}
+		}
+		if consistent {
+			return values
+		}
+	}
+}
+
+func evaluate(n *Node, w world) Action {
+	if n.IsAction() {
+		return n.NodeType.(Action)
+	}
+	var isTrue bool
+	switch t := testOf(n); t.condition {
+	case IsHealthAbove:
+		isTrue = w.health > t.param
+	case IsPhAbove:
+		isTrue = w.ph > t.param
+	case IsFoodValueAheadAbove:
+		isTrue = w.foodValue > t.param
+	default:
+		isTrue = w.values[t]
+	}
+	if isTrue {
+		return evaluate(n.YesNode, w)
+	}
+	return evaluate(n.NoNode, w)
+}
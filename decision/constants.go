@@ -30,8 +30,27 @@ const (
 	IsRandomFiftyPercent
 	IsHealthAboveFiftyPercent
 	IsHealthyPhHere
+	IsHealthAbove
+	IsRandomBelow
+	IsPhAbove
+	IsFoodValueAheadAbove
+	IsFlag0Set
+	IsFlag1Set
+	IsFlag2Set
+	IsFlag3Set
+	ActSetFlag0 Action = iota
+	ActSetFlag1
+	ActSetFlag2
+	ActSetFlag3
+	ActClearFlag0
+	ActClearFlag1
+	ActClearFlag2
+	ActClearFlag3
 )
 
+// MaxMemoryFlags is the most memory flags an organism can use
+const MaxMemoryFlags = 4
+
 // Define slices
 var (
 	Actions = [...]Action{
@@ -59,6 +78,12 @@ var (
 		IsRandomFiftyPercent,
 		IsHealthAboveFiftyPercent,
 		IsHealthyPhHere,
+		IsHealthAbove,
+		IsRandomBelow,
+		IsPhAbove,
+		IsFoodValueAheadAbove,
+		// memory flag conditions are in FlagConditions, since only the
+		// configured number of them are used
 	}
 	Map = map[interface{}]string{
 		ActAttack:                 "Attack",
@@ -83,5 +108,38 @@ var (
 		IsRandomFiftyPercent:      "IsRandomFiftyPercent",
 		IsHealthAboveFiftyPercent: "IsHealthAboveFiftyPercent",
 		IsHealthyPhHere:           "IsHealthyPhHere",
+		IsHealthAbove:             "If Health Above",
+		IsRandomBelow:             "If Random Below",
+		IsPhAbove:                 "If Ph Above",
+		IsFoodValueAheadAbove:     "If Food Value Ahead Above",
+		IsFlag0Set:                "If Flag 0 Set",
+		IsFlag1Set:                "If Flag 1 Set",
+		IsFlag2Set:                "If Flag 2 Set",
+		IsFlag3Set:                "If Flag 3 Set",
+		ActSetFlag0:               "Set Flag 0",
+		ActSetFlag1:               "Set Flag 1",
+		ActSetFlag2:               "Set Flag 2",
+		ActSetFlag3:               "Set Flag 3",
+		ActClearFlag0:             "Clear Flag 0",
+		ActClearFlag1:             "Clear Flag 1",
+		ActClearFlag2:             "Clear Flag 2",
+		ActClearFlag3:             "Clear Flag 3",
+	}
+	// Parameters lists the Conditions that take a numeric parameter, and the
+	// values it can have. Parameters are fractions of the range of the value
+	// checked (eg. MinPh to MaxPh), so they suit any config.
+	Parameters = map[Condition]Parameter{
+		IsHealthAbove:         {Min: 0, Max: 1, MaxNudge: 0.1, Precision: 0.01},
+		IsRandomBelow:         {Min: 0, Max: 1, MaxNudge: 0.1, Precision: 0.01},
+		IsPhAbove:             {Min: 0, Max: 1, MaxNudge: 0.05, Precision: 0.01},
+		IsFoodValueAheadAbove: {Min: 0, Max: 1, MaxNudge: 0.1, Precision: 0.01},
 	}
 )
+
+// FlagConditions, FlagSetActions and FlagClearActions are indexed by the
+// memory flag they check or change
+var (
+	FlagConditions   = [MaxMemoryFlags]Condition{IsFlag0Set, IsFlag1Set, IsFlag2Set, IsFlag3Set}
+	FlagSetActions   = [MaxMemoryFlags]Action{ActSetFlag0, ActSetFlag1, ActSetFlag2, ActSetFlag3}
+	FlagClearActions = [MaxMemoryFlags]Action{ActClearFlag0, ActClearFlag1, ActClearFlag2, ActClearFlag3}
+)
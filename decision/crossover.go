@@ -0,0 +1,34 @@
+package decision
+
+import (
+	"math/rand"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+// Crossover returns a new tree made by copying the first parent's tree and
+// replacing one of its subtrees, chosen at random, with a copy of a random
+// subtree of the second parent's. The second subtree is only chosen from
+// those that keep the result within MaxDecisionTreeSize.
+func Crossover(first, second *Tree, g *config.Globals, r *rand.Rand) *Tree {
+	tree := first.CopyTree()
+	firstNodes := tree.getNodes()
+	replaced := firstNodes[r.Intn(len(firstNodes))]
+
+	maxSubtreeSize := g.MaxDecisionTreeSize - (len(firstNodes) - len(replaced.getNodes()))
+	candidates := make([]*Node, 0, second.Size())
+	for _, node := range second.getNodes() {
+		if len(node.getNodes()) <= maxSubtreeSize {
+			candidates = append(candidates, node)
+		}
+	}
+
+	// nothing fits only if the first tree is already over the maximum size,
+	// in which case it's left unchanged
+	if len(candidates) > 0 {
+		*replaced = *candidates[r.Intn(len(candidates))].CopyNode()
+	}
+
+	tree.refresh()
+	return tree
+}
@@ -0,0 +1,48 @@
+package decision
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+func TestCrossover(t *testing.T) {
+	first, _ := ParseTree("(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))")
+	second, _ := ParseTree("(IsHealthyPhHere Chemosynthesis (IfOrganismAhead Attack TurnRight))")
+	globals := config.Globals{MaxDecisionTreeSize: 7}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		child := Crossover(first, second, &globals, r)
+		assert.LessOrEqual(t, child.Size(), globals.MaxDecisionTreeSize)
+		assert.Equal(t, len(child.getNodes()), child.Size())
+		assert.Equal(t, child.Serialize(), child.ID)
+
+		// every node comes from one of the parents
+		for _, name := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(child.String())) {
+			assert.True(t, strings.Contains(first.String(), name) || strings.Contains(second.String(), name), name)
+		}
+	}
+
+	// parents are unchanged
+	assert.Equal(t, "(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))", first.String())
+	assert.Equal(t, "(IsHealthyPhHere Chemosynthesis (IfOrganismAhead Attack TurnRight))", second.String())
+}
+
+func TestCrossoverRespectsMaxSize(t *testing.T) {
+	globals := config.Globals{MaxDecisionTreeSize: 32}
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		first, second := TreeFromAction(GetRandomAction(&globals, r)), TreeFromAction(GetRandomAction(&globals, r))
+		for mutations := 0; mutations < 40; mutations++ {
+			first = MutateTree(first, &globals, r)
+			second = MutateTree(second, &globals, r)
+		}
+		child := Crossover(first, second, &globals, r)
+		assert.LessOrEqual(t, child.Size(), globals.MaxDecisionTreeSize)
+	}
+}
@@ -0,0 +1,196 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Export is a decision tree to write with WriteDOT or WriteJSON, the stats of
+// the organisms that have used it, and the visit counts and last path of an
+// organism or library entry (see Trace)
+type Export struct {
+	Tree   *Tree
+	Stats  Stats
+	Visits []int // nil if no visits were recorded
+	Path   []int
+}
+
+// visits returns the visit count of the node at the given index
+func (e Export) visits(index int) int {
+	if index < len(e.Visits) {
+		return e.Visits[index]
+	}
+	return 0
+}
+
+// onPath returns true if the node at the given index was on the last path
+func (e Export) onPath(index int) bool {
+	for _, i := range e.Path {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// Exports returns the trees, stats and visit counts of library entries to write with
+// WriteDOT or WriteJSON. Entries must have trees (see Library.RecordDeath).
+func Exports(entries []*Entry) []Export {
+	exports := make([]Export, len(entries))
+	for i, entry := range entries {
+		exports[i] = entry.Export()
+	}
+	return exports
+}
+
+// WriteTreesForFile writes the trees to w as Graphviz DOT or JSON, depending
+// on the extension of the file path they're being written to
+func WriteTreesForFile(path string, w io.Writer, trees []Export) error {
+	switch filepath.Ext(path) {
+	case ".dot", ".gv":
+		return WriteDOT(w, trees)
+	case ".json":
+		return WriteJSON(w, trees)
+	}
+	return fmt.Errorf("unsupported tree file type: %s (use .dot, .gv or .json)", path)
+}
+
+// WriteDOT writes the trees to w as a single Graphviz DOT graph, with each
+// tree in its own cluster labelled with its stats. Each node is labelled with
+// its visit count (and percentage of the root's visits), and nodes used last
+// cycle are drawn in bold red.
+func WriteDOT(w io.Writer, trees []Export) error {
+	var builder strings.Builder
+	builder.WriteString("digraph trees {\n")
+	builder.WriteString("\tnode [fontname=\"Helvetica\"];\n")
+	builder.WriteString("\tedge [fontname=\"Helvetica\"];\n")
+	for i, export := range trees {
+		prefix := fmt.Sprintf("t%d_", i)
+		fmt.Fprintf(&builder, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(&builder, "\t\tlabel=%q;\n", treeLabel(export))
+		for index, node := range export.Tree.getNodes() {
+			fmt.Fprintf(&builder, "\t\t%s%d [%s];\n", prefix, index, dotAttributes(node, export, index))
+		}
+		export.Tree.writeDOTEdges(&builder, prefix, 0)
+		builder.WriteString("\t}\n")
+	}
+	builder.WriteString("}\n")
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+func treeLabel(export Export) string {
+	stats := export.Stats
+	return fmt.Sprintf("%s\ncarriers: %d, births: %d, children: %d, mean lifespan: %.1f",
+		export.Tree.String(), stats.Carriers, stats.Births, stats.Children, stats.MeanLifespan())
+}
+
+func dotAttributes(n *Node, export Export, index int) string {
+	visits, percent := export.visits(index), 0.0
+	if rootVisits := export.visits(0); rootVisits > 0 {
+		percent = 100 * float64(visits) / float64(rootVisits)
+	}
+	label := fmt.Sprintf("%s\nvisits: %d (%.0f%%)", n.Name(), visits, percent)
+	attributes := fmt.Sprintf("label=%q", label)
+	if n.IsCondition() {
+		attributes += ", shape=diamond"
+	} else {
+		attributes += ", shape=box"
+	}
+	if export.onPath(index) {
+		attributes += ", color=red, penwidth=3"
+	}
+	return attributes
+}
+
+// writeDOTEdges writes the edges from the Node at the given index to its
+// branches, and from them to theirs, returning the index after its last child
+func (n *Node) writeDOTEdges(builder *strings.Builder, prefix string, index int) int {
+	if n.IsAction() {
+		return index + 1
+	}
+	yesIndex := index + 1
+	noIndex := n.YesNode.writeDOTEdges(builder, prefix, yesIndex)
+	fmt.Fprintf(builder, "\t\t%s%d -> %s%d [label=\"yes\"];\n", prefix, index, prefix, yesIndex)
+	fmt.Fprintf(builder, "\t\t%s%d -> %s%d [label=\"no\"];\n", prefix, index, prefix, noIndex)
+	return n.NoNode.writeDOTEdges(builder, prefix, noIndex)
+}
+
+// jsonTree is the structure written by WriteJSON for each tree
+type jsonTree struct {
+	ID    string    `json:"id"`
+	Text  string    `json:"text"`
+	Size  int       `json:"size"`
+	Stats jsonStats `json:"stats"`
+	Root  *jsonNode `json:"root"`
+}
+
+type jsonStats struct {
+	Carriers     int     `json:"carriers"`
+	FirstSeen    int     `json:"first_seen"`
+	Births       int     `json:"births"`
+	Children     int     `json:"children"`
+	Deaths       int     `json:"deaths"`
+	MeanLifespan float64 `json:"mean_lifespan"`
+	HealthGained float64 `json:"health_gained"`
+}
+
+type jsonNode struct {
+	Name          string    `json:"name"`
+	Param         *float64  `json:"param,omitempty"`
+	Visits        int       `json:"visits"`
+	UsedLastCycle bool      `json:"used_last_cycle"`
+	Yes           *jsonNode `json:"yes,omitempty"`
+	No            *jsonNode `json:"no,omitempty"`
+}
+
+// WriteJSON writes the trees to w as a JSON array. Each tree includes its
+// stats, its text format and its nodes, with each Condition's branches nested
+// under "yes" and "no".
+func WriteJSON(w io.Writer, trees []Export) error {
+	exported := make([]jsonTree, len(trees))
+	for i, export := range trees {
+		tree, stats := export.Tree, export.Stats
+		exported[i] = jsonTree{
+			ID:   tree.ID,
+			Text: tree.String(),
+			Size: len(tree.getNodes()),
+			Stats: jsonStats{
+				Carriers:     stats.Carriers,
+				FirstSeen:    stats.FirstSeen,
+				Births:       stats.Births,
+				Children:     stats.Children,
+				Deaths:       stats.Deaths,
+				MeanLifespan: stats.MeanLifespan(),
+				HealthGained: stats.HealthGained,
+			},
+		}
+		exported[i].Root, _ = tree.Node.toJSON(export, 0)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(exported)
+}
+
+// toJSON returns the Node at the given index and its children, with the
+// export's visits, and the index after its last child
+func (n *Node) toJSON(export Export, index int) (*jsonNode, int) {
+	node := &jsonNode{
+		Name:          formatName(Map[n.NodeType]),
+		Visits:        export.visits(index),
+		UsedLastCycle: export.onPath(index),
+	}
+	if n.HasParam() {
+		param := n.Param
+		node.Param = &param
+	}
+	if n.IsAction() {
+		return node, index + 1
+	}
+	node.Yes, index = n.YesNode.toJSON(export, index+1)
+	node.No, index = n.NoNode.toJSON(export, index)
+	return node, index
+}
@@ -0,0 +1,72 @@
+package decision
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportedTree(t *testing.T) Export {
+	tree, err := ParseTree("(IfFoodAhead Eat (IfHealthAbove:0.5 MoveAhead TurnLeft))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, trace := Compile(tree.Node), NewTrace(tree.Node)
+	for _, checker := range []bitsChecker{1 << uint(IsFoodAhead), 0, 0, 0} {
+		program.Run(checker, nil, trace)
+	}
+	return Export{Tree: tree, Stats: Stats{Carriers: 3}, Visits: trace.Visits, Path: trace.Path}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteDOT(&buffer, []Export{exportedTree(t), {Tree: TreeFromAction(ActEat)}}))
+	dot := buffer.String()
+
+	assert.True(t, strings.HasPrefix(dot, "digraph trees {\n"), dot)
+	assert.Contains(t, dot, "subgraph cluster_0 {")
+	assert.Contains(t, dot, "subgraph cluster_1 {")
+	assert.Contains(t, dot, "carriers: 3")
+	assert.Contains(t, dot, `t0_0 [label="If Food Ahead\nvisits: 4 (100%)", shape=diamond, color=red, penwidth=3];`)
+	assert.Contains(t, dot, `t0_1 [label="Eat\nvisits: 1 (25%)", shape=box];`)
+	assert.Contains(t, dot, `t0_2 [label="If Health Above 0.5\nvisits: 3 (75%)", shape=diamond, color=red, penwidth=3];`)
+	assert.Contains(t, dot, `t0_4 [label="Turn Left\nvisits: 3 (75%)", shape=box, color=red, penwidth=3];`)
+	assert.Contains(t, dot, `t0_0 -> t0_1 [label="yes"];`)
+	assert.Contains(t, dot, `t0_0 -> t0_2 [label="no"];`)
+	assert.Contains(t, dot, `t0_2 -> t0_4 [label="no"];`)
+	assert.Contains(t, dot, `t1_0 [label="Eat\nvisits: 0 (0%)", shape=box];`)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteJSON(&buffer, []Export{exportedTree(t)}))
+
+	var trees []jsonTree
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &trees))
+	assert.Len(t, trees, 1)
+	tree := trees[0]
+	assert.Equal(t, "(IfFoodAhead Eat (IfHealthAbove:0.5 MoveAhead TurnLeft))", tree.Text)
+	assert.Equal(t, 5, tree.Size)
+	assert.Equal(t, 3, tree.Stats.Carriers)
+	assert.Equal(t, "IfFoodAhead", tree.Root.Name)
+	assert.Nil(t, tree.Root.Param)
+	assert.Equal(t, 4, tree.Root.Visits)
+	assert.Equal(t, "Eat", tree.Root.Yes.Name)
+	assert.Nil(t, tree.Root.Yes.Yes)
+	assert.Equal(t, 0.5, *tree.Root.No.Param)
+	assert.True(t, tree.Root.No.No.UsedLastCycle)
+	assert.False(t, tree.Root.Yes.UsedLastCycle)
+}
+
+func TestWriteTreesForFile(t *testing.T) {
+	trees := []Export{{Tree: TreeFromAction(ActEat)}}
+	for path, prefix := range map[string]string{"trees.dot": "digraph", "trees.gv": "digraph", "trees.json": "["} {
+		var buffer bytes.Buffer
+		assert.NoError(t, WriteTreesForFile(path, &buffer, trees), path)
+		assert.True(t, strings.HasPrefix(buffer.String(), prefix), path)
+	}
+	assert.Error(t, WriteTreesForFile("trees.txt", &bytes.Buffer{}, trees))
+}
@@ -0,0 +1,227 @@
+package decision
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Trees can be written as S-expressions, where each Condition is followed by
+// its yes and no branches in parentheses, eg.
+//
+//	(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))
+//
+// Node names are those in Map with spaces removed, so the format doesn't
+// depend on the order Actions and Conditions are declared in. Conditions that
+// take a parameter have it appended after a colon, eg. IfHealthAbove:0.35.
+// Anything from a ';' to the end of a line is a comment.
+
+var nodeTypesByName = make(map[string]interface{}, len(Map))
+
+func init() {
+	for nodeType, name := range Map {
+		nodeTypesByName[formatName(name)] = nodeType
+	}
+}
+
+// formatName returns the name used for a node type in the text format
+func formatName(name string) string {
+	return strings.ReplaceAll(name, " ", "")
+}
+
+// formatToken returns the Node's name in the text format, with its parameter
+// if it has one
+func (n *Node) formatToken() string {
+	name := formatName(Map[n.NodeType])
+	if n.HasParam() {
+		return fmt.Sprintf("%s:%g", name, n.Param)
+	}
+	return name
+}
+
+// String returns the Node and its children on a single line in the text format
+func (n *Node) String() string {
+	var builder strings.Builder
+	n.writeString(&builder)
+	return builder.String()
+}
+
+func (n *Node) writeString(builder *strings.Builder) {
+	name := n.formatToken()
+	if n.IsAction() {
+		builder.WriteString(name)
+		return
+	}
+	builder.WriteString("(")
+	builder.WriteString(name)
+	builder.WriteString(" ")
+	n.YesNode.writeString(builder)
+	builder.WriteString(" ")
+	n.NoNode.writeString(builder)
+	builder.WriteString(")")
+}
+
+// Format returns the Node and its children in the text format, with each
+// branch on its own line and indented two spaces further than its Condition
+func (n *Node) Format() string {
+	var builder strings.Builder
+	n.writeFormatted(&builder, "")
+	return builder.String()
+}
+
+func (n *Node) writeFormatted(builder *strings.Builder, indent string) {
+	name := n.formatToken()
+	if n.IsAction() {
+		builder.WriteString(name)
+		return
+	}
+	branchIndent := indent + "  "
+	builder.WriteString("(")
+	builder.WriteString(name)
+	builder.WriteString("\n" + branchIndent)
+	n.YesNode.writeFormatted(builder, branchIndent)
+	builder.WriteString("\n" + branchIndent)
+	n.NoNode.writeFormatted(builder, branchIndent)
+	builder.WriteString(")")
+}
+
+// ParseTree returns the decision Tree described by a string in the text
+// format written by String or Format
+func ParseTree(text string) (*Tree, error) {
+	p := &parser{tokens: tokenize(text)}
+	node, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	if p.position < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q after end of tree", p.tokens[p.position])
+	}
+	tree := &Tree{Node: node}
+	tree.size = tree.CalcAndUpdateSize()
+	tree.ID = tree.Serialize()
+	return tree, nil
+}
+
+// tokenize splits text into parentheses and node names, dropping whitespace
+// and comments
+func tokenize(text string) []string {
+	tokens := make([]string, 0)
+	var name strings.Builder
+	endName := func() {
+		if name.Len() > 0 {
+			tokens = append(tokens, name.String())
+			name.Reset()
+		}
+	}
+	inComment := false
+	for _, r := range text {
+		switch {
+		case inComment:
+			inComment = r != '\n'
+		case r == ';':
+			endName()
+			inComment = true
+		case r == '(' || r == ')':
+			endName()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			endName()
+		default:
+			name.WriteRune(r)
+		}
+	}
+	endName()
+	return tokens
+}
+
+type parser struct {
+	tokens   []string
+	position int
+}
+
+func (p *parser) next() (string, error) {
+	if p.position >= len(p.tokens) {
+		return "", errors.New("unexpected end of tree")
+	}
+	token := p.tokens[p.position]
+	p.position++
+	return token, nil
+}
+
+// parseNode parses either a single Action name or a parenthesized Condition
+// followed by its yes and no branches
+func (p *parser) parseNode() (*Node, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if token == ")" {
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	if token != "(" {
+		nodeType, ok := nodeTypesByName[token]
+		if !ok {
+			return nil, fmt.Errorf("unknown node %q", token)
+		}
+		action, ok := nodeType.(Action)
+		if !ok {
+			return nil, fmt.Errorf("condition %q must be followed by two branches in parentheses", token)
+		}
+		return NodeFromAction(action), nil
+	}
+
+	token, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	name, paramText, hasParam := strings.Cut(token, ":")
+	nodeType, ok := nodeTypesByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown node %q", name)
+	}
+	condition, ok := nodeType.(Condition)
+	if !ok {
+		return nil, fmt.Errorf("expected a condition after \"(\", found action %q", token)
+	}
+	param, err := parseParam(condition, paramText, hasParam)
+	if err != nil {
+		return nil, err
+	}
+	yes, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	no, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	if token, err = p.next(); err != nil {
+		return nil, err
+	} else if token != ")" {
+		return nil, fmt.Errorf("expected \")\" after branches of %s, found %q", formatName(Map[condition]), token)
+	}
+	return &Node{NodeType: condition, Param: param, YesNode: yes, NoNode: no}, nil
+}
+
+// parseParam parses the parameter following a Condition's name, which must
+// be given for exactly those Conditions listed in Parameters and is bounded
+// to the parameter's range and precision
+func parseParam(condition Condition, text string, given bool) (float64, error) {
+	name := formatName(Map[condition])
+	parameter, takesParam := Parameters[condition]
+	switch {
+	case !takesParam && given:
+		return 0, fmt.Errorf("condition %s doesn't take a parameter", name)
+	case !takesParam:
+		return 0, nil
+	case !given:
+		return 0, fmt.Errorf("condition %s requires a parameter, eg. %s:%g", name, name, parameter.Min)
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid parameter %q for %s", text, name)
+	}
+	return parameter.bound(value), nil
+}
@@ -0,0 +1,96 @@
+package decision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+func TestTreeString(t *testing.T) {
+	tree := &Tree{Node: &Node{
+		NodeType: IsFoodAhead,
+		YesNode:  NodeFromAction(ActEat),
+		NoNode: &Node{
+			NodeType: CanMove,
+			YesNode:  NodeFromAction(ActMove),
+			NoNode:   NodeFromAction(ActTurnLeft),
+		},
+	}}
+
+	assert.Equal(t, "(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))", tree.String())
+	assert.Equal(t, "(IfFoodAhead\n  Eat\n  (IfCanMoveAhead\n    MoveAhead\n    TurnLeft))", tree.Format())
+}
+
+func TestParseTree(t *testing.T) {
+	text := `
+		; eat anything ahead, otherwise wander
+		(IfFoodAhead Eat
+			(IfCanMoveAhead MoveAhead TurnLeft)) ; turn when blocked`
+	tree, err := ParseTree(text)
+	require.NoError(t, err)
+	assert.Equal(t, 5, tree.Size())
+	assert.Equal(t, "0902080405", tree.ID)
+	assert.Equal(t, CanMove, tree.NoNode.NodeType, tree.String())
+	assert.Equal(t, ActTurnLeft, tree.NoNode.NoNode.NodeType, tree.String())
+}
+
+func TestParseTreeParams(t *testing.T) {
+	tree, err := ParseTree("(IfHealthAbove:0.35 (IfPhAbove:0.75 Eat Chemosynthesis) (IfFoodValueAheadAbove:0.4 Eat MoveAhead))")
+	require.NoError(t, err)
+	assert.Equal(t, IsHealthAbove, tree.Node.NodeType)
+	assert.Equal(t, 0.35, tree.Node.Param)
+	assert.Equal(t, "22[0.35]24[0.75]020325[0.4]0204", tree.ID)
+	expected := "If Health Above 0.35\n├─If Ph Above 0.75\n│ ├─Eat\n│ └─Chemosynthesis\n" +
+		"└─If Food Value Ahead Above 0.4\n  ├─Eat\n  └─Move Ahead\n"
+	assert.Equal(t, expected, tree.Print())
+
+	// parameters are bounded to their range and precision
+	tree, err = ParseTree("(IfRandomBelow:1.5 Eat (IfHealthAbove:0.333 Eat Attack))")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, tree.Param)
+	assert.Equal(t, 0.33, tree.NoNode.Param)
+}
+
+func TestParseTreeErrors(t *testing.T) {
+	testCases := []string{
+		"",
+		"Jump",
+		"IfFoodAhead",
+		"(Eat MoveAhead TurnLeft)",
+		"(IfFoodAhead Eat)",
+		"(IfFoodAhead Eat MoveAhead TurnLeft)",
+		"(IfFoodAhead Eat MoveAhead",
+		"Eat MoveAhead",
+		")",
+		"(IfHealthAbove Eat MoveAhead)",
+		"(IfHealthAbove:high Eat MoveAhead)",
+		"(IfFoodAhead:1 Eat MoveAhead)",
+		"Eat:1",
+	}
+	for _, text := range testCases {
+		_, err := ParseTree(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	globals := config.Globals{MaxDecisionTreeSize: 32}
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 100; i++ {
+		tree := TreeFromAction(GetRandomAction(&globals, r))
+		for mutations := 0; mutations < 20; mutations++ {
+			tree = MutateTree(tree, &globals, r)
+		}
+
+		for _, text := range []string{tree.String(), tree.Format()} {
+			parsed, err := ParseTree(text)
+			require.NoError(t, err, text)
+			assert.Equal(t, tree.ID, parsed.ID, text)
+			assert.Equal(t, tree.Size(), parsed.Size(), text)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package decision
+
+import "sort"
+
+// Stats tracks the success of a decision tree across every organism that has
+// used it
+type Stats struct {
+	Carriers      int     // living organisms currently using the tree
+	FirstSeen     int     // cycle the tree first appeared
+	Births        int     // organisms born (or generated) using the tree
+	Children      int     // children spawned by organisms using the tree
+	Deaths        int     // organisms using the tree that have died
+	TotalLifespan int     // sum of the ages of organisms that died using the tree
+	HealthGained  float64 // cumulative health change of all organisms while using the tree
+	ExtinctSince  int     // cycle the last carrier died, if the tree has none
+}
+
+// MeanLifespan returns the average age at death of organisms using the tree,
+// or 0 if none have died
+func (s Stats) MeanLifespan() float64 {
+	if s.Deaths == 0 {
+		return 0
+	}
+	return float64(s.TotalLifespan) / float64(s.Deaths)
+}
+
+// Entry is a decision tree in a Library and the stats of every organism that
+// has used it. Once the tree has no living carriers only its ID and stats are
+// kept, and Tree and Visits are nil.
+type Entry struct {
+	ID     string
+	Tree   *Tree
+	Stats  Stats
+	Visits []int // visits to each node by all carriers, in print order
+}
+
+// Export returns the entry's tree, stats and visit counts to write with
+// WriteDOT or WriteJSON
+func (e *Entry) Export() Export {
+	return Export{Tree: e.Tree, Stats: e.Stats, Visits: e.Visits}
+}
+
+// Library contains every decision tree seen in a simulation, keyed by ID.
+// Trees with no living carriers keep only their stats, and can be evicted
+// altogether once they've had none for long enough (see Evict).
+type Library map[string]*Entry
+
+// NewLibrary returns an empty Library
+func NewLibrary() Library {
+	return make(Library)
+}
+
+// RegisterAndReturnTree adds a copy of the tree to the library if no tree
+// with the same ID exists yet, and returns the library's tree. An extinct
+// tree that reappears takes a copy of the tree's nodes again.
+func (l Library) RegisterAndReturnTree(tree *Tree) *Tree {
+	entry, ok := l[tree.ID]
+	if ok && entry.Tree != nil {
+		return entry.Tree
+	}
+	if !ok {
+		entry = &Entry{ID: tree.ID}
+		l[tree.ID] = entry
+	}
+	registered := tree.CopyTree()
+	registered.size = registered.CalcAndUpdateSize()
+	entry.Tree, entry.Visits = registered, make([]int, registered.size)
+	return registered
+}
+
+// RecordBirth registers the tree of a newly-created organism and counts it as
+// a carrier. Returns true if the tree had never been seen before.
+func (l Library) RecordBirth(tree *Tree, cycle int) bool {
+	_, exists := l[tree.ID]
+	l.RegisterAndReturnTree(tree)
+	stats := &l[tree.ID].Stats
+	if !exists {
+		stats.FirstSeen = cycle
+	}
+	stats.Carriers++
+	stats.Births++
+	return !exists
+}
+
+// RecordChild counts a child spawned by an organism using the given tree
+func (l Library) RecordChild(id string) {
+	if entry, ok := l[id]; ok {
+		entry.Stats.Children++
+	}
+}
+
+// RecordDeath counts the death of an organism using the given tree at the
+// given age and cycle. Once the tree has no carriers left, only its stats
+// are kept.
+func (l Library) RecordDeath(id string, age, cycle int) {
+	if entry, ok := l[id]; ok {
+		entry.Stats.Carriers--
+		entry.Stats.Deaths++
+		entry.Stats.TotalLifespan += age
+		if entry.Stats.Carriers == 0 {
+			entry.Stats.ExtinctSince = cycle
+			entry.Tree, entry.Visits = nil, nil
+		}
+	}
+}
+
+// Evict removes the trees that have had no carriers for at least the given
+// number of cycles, and returns how many were removed. An evicted tree that
+// appears again is recorded as never seen before.
+func (l Library) Evict(cycle, cycles int) int {
+	evicted := 0
+	for id, entry := range l {
+		if entry.Stats.Carriers == 0 && cycle-entry.Stats.ExtinctSince >= cycles {
+			delete(l, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// RecordHealthChange adds an organism's health change to the total for the
+// tree it used
+func (l Library) RecordHealthChange(id string, change float64) {
+	if entry, ok := l[id]; ok {
+		entry.Stats.HealthGained += change
+	}
+}
+
+// RecordVisits adds the path an organism's tree took last cycle (see
+// Trace.Path) to the visit counts of the library's tree with the given ID,
+// which accumulate across all carriers
+func (l Library) RecordVisits(id string, path []int) {
+	if entry, ok := l[id]; ok && entry.Tree != nil {
+		for _, index := range path {
+			entry.Visits[index]++
+		}
+	}
+}
+
+// Sorted returns every entry in the library, ordered by the given less
+// function and then by ID
+func (l Library) Sorted(less func(a, b *Entry) bool) []*Entry {
+	entries := make([]*Entry, 0, len(l))
+	for _, entry := range l {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if less(entries[i], entries[j]) {
+			return true
+		}
+		if less(entries[j], entries[i]) {
+			return false
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// ByCarriers orders entries from most to fewest living carriers
+func ByCarriers(a, b *Entry) bool {
+	return a.Stats.Carriers > b.Stats.Carriers
+}
@@ -0,0 +1,94 @@
+package decision
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLibraryStats(t *testing.T) {
+	lib := NewLibrary()
+	eat := TreeFromAction(ActEat)
+	move := TreeFromAction(ActMove)
+
+	assert.True(t, lib.RecordBirth(eat, 3))
+	assert.False(t, lib.RecordBirth(eat.CopyTree(), 5))
+	assert.True(t, lib.RecordBirth(move, 5))
+	lib.RecordChild(eat.ID)
+	lib.RecordHealthChange(eat.ID, 1.5)
+	lib.RecordHealthChange(eat.ID, -0.5)
+	lib.RecordDeath(eat.ID, 10, 13)
+
+	stats := lib[eat.ID].Stats
+	assert.Equal(t, 1, stats.Carriers)
+	assert.Equal(t, 3, stats.FirstSeen)
+	assert.Equal(t, 2, stats.Births)
+	assert.Equal(t, 1, stats.Children)
+	assert.Equal(t, 1.0, stats.HealthGained)
+	assert.Equal(t, 10.0, stats.MeanLifespan())
+
+	// registered trees are copies, unaffected by changes to the original
+	eat.NodeType = ActAttack
+	assert.Equal(t, "Eat\n", lib["02"].Tree.Print())
+
+	sorted := lib.Sorted(ByCarriers)
+	assert.Equal(t, []string{"02", "04"}, []string{sorted[0].ID, sorted[1].ID})
+
+	// exports carry the library's stats with the tree
+	export := Exports(sorted)[0]
+	assert.Equal(t, "Eat", export.Tree.String())
+	assert.Equal(t, stats, export.Stats)
+}
+
+func TestLibraryEviction(t *testing.T) {
+	lib := NewLibrary()
+	eat := TreeFromAction(ActEat)
+	lib.RecordBirth(eat, 0)
+	lib.RecordDeath(eat.ID, 4, 4)
+
+	// extinct trees keep only their stats
+	assert.Nil(t, lib[eat.ID].Tree)
+	assert.Equal(t, 1, lib[eat.ID].Stats.Deaths)
+	assert.Equal(t, 4, lib[eat.ID].Stats.ExtinctSince)
+
+	// and take their nodes back if they reappear
+	assert.False(t, lib.RecordBirth(eat, 6))
+	assert.Equal(t, "Eat\n", lib[eat.ID].Tree.Print())
+	assert.Equal(t, 0, lib.Evict(100, 10))
+
+	lib.RecordDeath(eat.ID, 2, 8)
+	assert.Equal(t, 0, lib.Evict(17, 10))
+	assert.Equal(t, 1, lib.Evict(18, 10))
+	assert.Empty(t, lib)
+	assert.True(t, lib.RecordBirth(eat, 20))
+}
+
+func TestLibraryVisits(t *testing.T) {
+	tree, err := ParseTree("(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary()
+	lib.RecordBirth(tree, 0)
+	program, carrier := Compile(tree.Node), NewTrace(tree.Node)
+
+	// visit IfFoodAhead -> IfCanMoveAhead -> TurnLeft, then IfFoodAhead -> Eat
+	lib.RecordVisits(tree.ID, []int{0, 2, 4})
+	lib.RecordVisits(tree.ID, []int{0, 1})
+	assert.Equal(t, []int{2, 1, 1, 0, 1}, lib[tree.ID].Visits)
+
+	// a program's path gives the same counts
+	checker := bitsChecker(1 << uint(IsFoodAhead))
+	program.Run(checker, nil, carrier)
+	assert.Equal(t, []int{0, 1}, carrier.Path)
+	lib.RecordVisits(tree.ID, carrier.Path)
+	assert.Equal(t, []int{3, 2, 1, 0, 1}, lib[tree.ID].Visits)
+
+	// the carrier's own counts are separate from the library's
+	assert.Equal(t, []int{1, 1, 0, 0, 0}, carrier.Visits)
+	assert.Equal(t, []int{3, 2, 1, 0, 1}, Exports([]*Entry{lib[tree.ID]})[0].Visits)
+
+	// extinct trees drop their visits along with their nodes
+	lib.RecordDeath(tree.ID, 1, 1)
+	assert.Nil(t, lib[tree.ID].Visits)
+}
@@ -0,0 +1,254 @@
+package decision
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+// maxOperatorAttempts limits how many operators are tried for each mutation,
+// since some can't be applied to every tree (eg. swapping branches of a tree
+// with no conditions)
+const maxOperatorAttempts = 10
+
+// operator makes a single change to a tree in place, returning false if it
+// couldn't be applied
+type operator func(t *Tree, g *config.Globals, r *rand.Rand) bool
+
+// operators lists every mutation operator with its configured weight
+func operators(g *config.Globals) []struct {
+	weight float64
+	apply  operator
+} {
+	return []struct {
+		weight float64
+		apply  operator
+	}{
+		{g.MutationWeightGrow, growAction},
+		{g.MutationWeightChangeAction, changeAction},
+		{g.MutationWeightCollapse, collapseCondition},
+		{g.MutationWeightChangeCondition, changeCondition},
+		{g.MutationWeightNudge, nudgeParam},
+		{g.MutationWeightRegrow, regrowSubtree},
+		{g.MutationWeightWrap, wrapSubtree},
+		{g.MutationWeightSwapBranches, swapBranches},
+		{g.MutationWeightDuplicate, duplicateSubtree},
+		{g.MutationWeightHoist, hoistSubtree},
+	}
+}
+
+// hasMutationWeights returns true if any mutation operator has a weight set
+func hasMutationWeights(g *config.Globals) bool {
+	for _, o := range operators(g) {
+		if o.weight > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mutateWeighted applies a single operator chosen at random according to
+// the configured weights
+func (t *Tree) mutateWeighted(g *config.Globals, r *rand.Rand) {
+	ops := operators(g)
+	total := 0.0
+	for _, o := range ops {
+		total += math.Max(o.weight, 0)
+	}
+	for attempt := 0; attempt < maxOperatorAttempts; attempt++ {
+		choice := r.Float64() * total
+		for _, o := range ops {
+			if o.weight <= 0 {
+				continue
+			}
+			choice -= o.weight
+			if choice < 0 {
+				if o.apply(t, g, r) {
+					t.size = t.CalcAndUpdateSize()
+					return
+				}
+				break
+			}
+		}
+	}
+}
+
+// mutationCount returns the number of operators to apply in a single
+// mutation, drawn from the configured distribution
+func mutationCount(g *config.Globals, r *rand.Rand) int {
+	extra := g.MutationCountMean - 1
+	if extra <= 0 {
+		return 1
+	}
+	switch g.MutationCountDistribution {
+	case config.MutationCountPoisson:
+		// Knuth's method, counting events beyond the first
+		limit, count, product := math.Exp(-extra), 0, r.Float64()
+		for product > limit {
+			count++
+			product *= r.Float64()
+		}
+		return 1 + count
+	case config.MutationCountGeometric:
+		// trials up to and including the first success
+		count := 1
+		for r.Float64() >= 1/g.MutationCountMean {
+			count++
+		}
+		return count
+	}
+	return int(math.Round(g.MutationCountMean))
+}
+
+// randomNode returns a random node of the tree, or nil if none match
+func (t *Tree) randomNode(r *rand.Rand, match func(n *Node) bool) *Node {
+	candidates := make([]*Node, 0, t.size)
+	for _, node := range t.getNodes() {
+		if match(node) {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[r.Intn(len(candidates))]
+}
+
+func anyNode(*Node) bool { return true }
+
+func actionNode(n *Node) bool { return n.IsAction() }
+
+func conditionNode(n *Node) bool { return n.IsCondition() }
+
+// randomSubtree returns a new random subtree of at most maxSize nodes
+func randomSubtree(maxSize int, g *config.Globals, r *rand.Rand) *Node {
+	if maxSize < 3 || r.Intn(2) == 0 {
+		return NodeFromAction(GetRandomAction(g, r))
+	}
+	branchSize := (maxSize - 1) / 2
+	node := &Node{}
+	node.setCondition(GetRandomCondition(g, r), r)
+	node.YesNode = randomSubtree(branchSize, g, r)
+	node.NoNode = randomSubtree(branchSize, g, r)
+	node.CalcAndUpdateSize()
+	return node
+}
+
+// growAction converts an action into a condition choosing between the
+// original action and a new one
+func growAction(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	if t.size > g.MaxDecisionTreeSize-2 {
+		return false
+	}
+	node := t.randomNode(r, actionNode)
+	originalAction := node.NodeType.(Action)
+	node.setCondition(GetRandomCondition(g, r), r)
+	if r.Intn(2) == 0 {
+		node.YesNode = NodeFromAction(GetRandomAction(g, r))
+		node.NoNode = NodeFromAction(originalAction)
+	} else {
+		node.YesNode = NodeFromAction(originalAction)
+		node.NoNode = NodeFromAction(GetRandomAction(g, r))
+	}
+	return true
+}
+
+// changeAction replaces an action with another
+func changeAction(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	t.randomNode(r, actionNode).NodeType = GetRandomAction(g, r)
+	return true
+}
+
+// collapseCondition replaces a condition and both its branches with a single
+// action
+func collapseCondition(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, conditionNode)
+	if node == nil {
+		return false
+	}
+	node.NodeType = GetRandomAction(g, r)
+	node.YesNode = nil
+	node.NoNode = nil
+	return true
+}
+
+// changeCondition replaces a condition with another, keeping its branches
+func changeCondition(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, conditionNode)
+	if node == nil {
+		return false
+	}
+	node.setCondition(GetRandomCondition(g, r), r)
+	return true
+}
+
+// nudgeParam changes the parameter of a parameterized condition by a small
+// amount
+func nudgeParam(t *Tree, _ *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, func(n *Node) bool { return n.HasParam() })
+	if node == nil {
+		return false
+	}
+	node.nudgeParam(r)
+	return true
+}
+
+// regrowSubtree deletes a subtree and grows a new random one in its place
+func regrowSubtree(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, anyNode)
+	*node = *randomSubtree(g.MaxDecisionTreeSize-(t.size-node.size), g, r)
+	return true
+}
+
+// wrapSubtree puts a subtree under a new condition, on a random side, with a
+// new action on the other
+func wrapSubtree(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	if t.size > g.MaxDecisionTreeSize-2 {
+		return false
+	}
+	node := t.randomNode(r, anyNode)
+	wrapped := *node
+	*node = Node{}
+	node.setCondition(GetRandomCondition(g, r), r)
+	if r.Intn(2) == 0 {
+		node.YesNode, node.NoNode = &wrapped, NodeFromAction(GetRandomAction(g, r))
+	} else {
+		node.YesNode, node.NoNode = NodeFromAction(GetRandomAction(g, r)), &wrapped
+	}
+	return true
+}
+
+// swapBranches swaps the yes and no branches of a condition
+func swapBranches(t *Tree, _ *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, conditionNode)
+	if node == nil {
+		return false
+	}
+	node.YesNode, node.NoNode = node.NoNode, node.YesNode
+	return true
+}
+
+// duplicateSubtree replaces one subtree with a copy of another, as long as
+// the result fits within the maximum tree size
+func duplicateSubtree(t *Tree, g *config.Globals, r *rand.Rand) bool {
+	source := t.randomNode(r, anyNode)
+	target := t.randomNode(r, func(n *Node) bool {
+		return n != source && t.size-n.size+source.size <= g.MaxDecisionTreeSize
+	})
+	if target == nil {
+		return false
+	}
+	*target = *source.CopyNode()
+	return true
+}
+
+// hoistSubtree replaces the whole tree with one of its subtrees
+func hoistSubtree(t *Tree, _ *config.Globals, r *rand.Rand) bool {
+	node := t.randomNode(r, func(n *Node) bool { return n != t.Node })
+	if node == nil {
+		return false
+	}
+	t.Node = node
+	return true
+}
@@ -0,0 +1,173 @@
+package decision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+func TestMutationOperators(t *testing.T) {
+	testCases := []struct {
+		name  string
+		apply operator
+		check func(original, mutated *Tree) bool
+	}{
+		{"grow", growAction, func(o, m *Tree) bool { return m.Size() == o.Size()+2 }},
+		{"change action", changeAction, func(o, m *Tree) bool { return m.Size() == o.Size() }},
+		{"collapse", collapseCondition, func(o, m *Tree) bool { return m.Size() < o.Size() }},
+		{"change condition", changeCondition, func(o, m *Tree) bool { return m.Size() == o.Size() }},
+		{"regrow", regrowSubtree, func(o, m *Tree) bool { return true }},
+		{"wrap", wrapSubtree, func(o, m *Tree) bool { return m.Size() == o.Size()+2 }},
+		{"swap branches", swapBranches, func(o, m *Tree) bool {
+			return m.Size() == o.Size() && m.ID != o.ID
+		}},
+		{"duplicate", duplicateSubtree, func(o, m *Tree) bool { return true }},
+		{"hoist", hoistSubtree, func(o, m *Tree) bool { return m.Size() < o.Size() }},
+	}
+
+	globals := config.Globals{MaxDecisionTreeSize: 15}
+	original, err := ParseTree("(IfFoodAhead Eat (IfCanMoveAhead (IfCanMoveAhead MoveAhead Attack) TurnLeft))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(5))
+	for _, tc := range testCases {
+		for i := 0; i < 50; i++ {
+			mutated := original.CopyTree()
+			assert.True(t, tc.apply(mutated, &globals, r), tc.name)
+			mutated.refresh()
+			assert.True(t, tc.check(original, mutated), "%s: %s", tc.name, mutated)
+			assert.LessOrEqual(t, mutated.Size(), globals.MaxDecisionTreeSize, tc.name)
+			assert.Equal(t, len(mutated.getNodes()), mutated.Size(), tc.name)
+			_, err := ParseTree(mutated.String())
+			assert.NoError(t, err, tc.name)
+		}
+	}
+	assert.Equal(t, "(IfFoodAhead Eat (IfCanMoveAhead (IfCanMoveAhead MoveAhead Attack) TurnLeft))", original.String())
+}
+
+func TestNudgeParam(t *testing.T) {
+	original, err := ParseTree("(IfPhAbove:0.5 Eat (IfFoodAhead Eat MoveAhead))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parameter := Parameters[IsPhAbove]
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		mutated := original.CopyTree()
+		assert.True(t, nudgeParam(mutated, &config.Globals{}, r))
+		mutated.refresh()
+		assert.Equal(t, IsPhAbove, mutated.NodeType)
+		assert.InDelta(t, 0.5, mutated.Param, parameter.MaxNudge+parameter.Precision/2)
+		assert.Equal(t, original.NoNode.String(), mutated.NoNode.String())
+	}
+
+	// the result always stays within the parameter's range
+	node := &Node{NodeType: IsPhAbove, Param: parameter.Max, YesNode: NodeFromAction(ActEat), NoNode: NodeFromAction(ActEat)}
+	for i := 0; i < 50; i++ {
+		node.nudgeParam(r)
+		assert.GreaterOrEqual(t, node.Param, parameter.Min)
+		assert.LessOrEqual(t, node.Param, parameter.Max)
+	}
+
+	noParams, _ := ParseTree("(IfFoodAhead Eat MoveAhead)")
+	assert.False(t, nudgeParam(noParams, &config.Globals{}, r))
+}
+
+func TestWeightedMutationsRespectMaxSize(t *testing.T) {
+	globals := config.Globals{
+		MaxDecisionTreeSize:        16,
+		MutationWeightGrow:         1,
+		MutationWeightRegrow:       1,
+		MutationWeightWrap:         1,
+		MutationWeightDuplicate:    1,
+		MutationWeightSwapBranches: 1,
+		MutationWeightHoist:        0.1,
+		MutationCountDistribution:  config.MutationCountPoisson,
+		MutationCountMean:          3,
+	}
+	r := rand.New(rand.NewSource(8))
+	tree := TreeFromAction(ActEat)
+	for i := 0; i < 1000; i++ {
+		tree = MutateTree(tree, &globals, r)
+		assert.LessOrEqual(t, tree.Size(), globals.MaxDecisionTreeSize)
+		assert.Equal(t, tree.Serialize(), tree.ID)
+	}
+}
+
+func TestPruneMutation(t *testing.T) {
+	original, err := ParseTree("(IfFoodAhead (IfFoodAhead Eat Attack) (IfCanMoveAhead TurnLeft TurnLeft))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(4))
+	for _, weight := range []float64{0, 1} {
+		globals := config.Globals{MaxDecisionTreeSize: 15, PruneMutationChance: 1, MutationWeightGrow: weight}
+		assert.Equal(t, "(IfFoodAhead Eat TurnLeft)", MutateTree(original, &globals, r).String())
+	}
+}
+
+func TestMutationCount(t *testing.T) {
+	testCases := []struct {
+		distribution string
+		mean         float64
+	}{
+		{config.MutationCountFixed, 3},
+		{config.MutationCountPoisson, 2.5},
+		{config.MutationCountGeometric, 2.5},
+		{config.MutationCountPoisson, 0},
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, tc := range testCases {
+		globals := config.Globals{MutationCountDistribution: tc.distribution, MutationCountMean: tc.mean}
+		total := 0
+		for i := 0; i < 10000; i++ {
+			count := mutationCount(&globals, r)
+			assert.GreaterOrEqual(t, count, 1)
+			total += count
+		}
+		expected := tc.mean
+		if expected < 1 {
+			expected = 1
+		}
+		assert.InDelta(t, expected, float64(total)/10000, 0.1, tc.distribution)
+	}
+}
+
+func TestRandomNodesUseMemoryFlags(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, flags := range []int{0, 2, MaxMemoryFlags + 1} {
+		globals := config.Globals{MemoryFlags: flags}
+		usedFlags := make(map[int]bool)
+		for i := 0; i < 500; i++ {
+			if flag, _, ok := GetRandomAction(&globals, r).Flag(); ok {
+				usedFlags[flag] = true
+			}
+			if flag, ok := GetRandomCondition(&globals, r).Flag(); ok {
+				usedFlags[flag] = true
+			}
+		}
+		expected := flags
+		if expected > MaxMemoryFlags {
+			expected = MaxMemoryFlags
+		}
+		assert.Len(t, usedFlags, expected, "memory flags: %d", flags)
+		for flag := range usedFlags {
+			assert.Less(t, flag, expected)
+		}
+	}
+
+	tree, err := ParseTree("(IfFlag1Set (IfFlag0Set ClearFlag1 SetFlag0) SetFlag1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flag, set, _ := tree.NoNode.NodeType.(Action).Flag()
+	assert.Equal(t, 1, flag)
+	assert.True(t, set)
+	flag, set, _ = tree.YesNode.YesNode.NodeType.(Action).Flag()
+	assert.Equal(t, 1, flag)
+	assert.False(t, set)
+}
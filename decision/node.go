@@ -8,10 +8,10 @@ import (
 // Node contains an Action or Condition NodeType and (if a Condition), child
 // references for its conditional branches
 type Node struct {
-	NodeType                      interface{}
-	InDecisionTree, UsedLastCycle bool
-	YesNode, NoNode               *Node
-	size                          int
+	NodeType        interface{}
+	Param           float64 // parameter of a Condition listed in Parameters
+	YesNode, NoNode *Node
+	size            int
 }
 
 // NodeFromAction creates a simple Node object from an Action type
@@ -35,9 +35,9 @@ func (n *Node) IsCondition() bool {
 // CopyNode returns a new Node with the same structure as the original
 func (n Node) CopyNode() *Node {
 	copy := &Node{
-		NodeType:      n.NodeType,
-		UsedLastCycle: n.UsedLastCycle,
-		size:          n.size,
+		NodeType: n.NodeType,
+		Param:    n.Param,
+		size:     n.size,
 	}
 	if n.IsAction() {
 		return copy
@@ -47,29 +47,6 @@ func (n Node) CopyNode() *Node {
 	return copy
 }
 
-// SetUsedInCurrentTree sets whether this Node is contained in a
-// currently-used decision tree
-func (n *Node) SetUsedInCurrentTree(isUsing bool) {
-	n.InDecisionTree = isUsing
-	if n.IsCondition() {
-		n.YesNode.SetUsedInCurrentTree(isUsing)
-		n.NoNode.SetUsedInCurrentTree(isUsing)
-	}
-}
-
-// ResetUsedLastCycle triggers this Node (and any previously-used child Nodes)
-// to set UsedLastCycle to false
-func (n *Node) ResetUsedLastCycle() {
-	n.UsedLastCycle = false
-	if n.IsCondition() {
-		if n.YesNode.UsedLastCycle {
-			n.YesNode.ResetUsedLastCycle()
-		} else {
-			n.NoNode.ResetUsedLastCycle()
-		}
-	}
-}
-
 // Serialize generates and returns a string representing a Node's
 // full Tree structure.
 //
@@ -79,6 +56,9 @@ func (n *Node) Serialize() string {
 	var buffer bytes.Buffer
 	nodeTypeString := fmt.Sprintf("%02d", n.NodeType)
 	buffer.WriteString(nodeTypeString)
+	if n.HasParam() {
+		buffer.WriteString(fmt.Sprintf("[%g]", n.Param))
+	}
 	if n.IsCondition() {
 		buffer.WriteString(n.YesNode.Serialize())
 		buffer.WriteString(n.NoNode.Serialize())
@@ -86,6 +66,15 @@ func (n *Node) Serialize() string {
 	return buffer.String()
 }
 
+// Name returns the Node's name from Map, followed by its parameter if it has
+// one
+func (n *Node) Name() string {
+	if n.HasParam() {
+		return fmt.Sprintf("%s %g", Map[n.NodeType], n.Param)
+	}
+	return Map[n.NodeType]
+}
+
 // getNodes returns a list of all nodes in a tree starting with the given root
 func (n *Node) getNodes() (nodes []*Node) {
 	nodes = make([]*Node, 0, n.size)
@@ -99,7 +88,9 @@ func (n *Node) getNodes() (nodes []*Node) {
 	return
 }
 
-func (n *Node) print(indent string, first, last bool) string {
+// print prints the Node and its children, marking those whose indexes are
+// in marked with ◀◀. index counts the nodes printed so far.
+func (n *Node) print(indent string, first, last bool, index *int, marked map[int]bool) string {
 	toPrint := indent
 	newIndent := indent
 	if first {
@@ -111,14 +102,15 @@ func (n *Node) print(indent string, first, last bool) string {
 		toPrint = fmt.Sprintf("%s├─", toPrint)
 		newIndent = fmt.Sprintf("%s│ ", newIndent)
 	}
-	if n.UsedLastCycle {
-		toPrint = fmt.Sprintf("%s%s ◀◀\n", toPrint, Map[n.NodeType])
+	if marked[*index] {
+		toPrint = fmt.Sprintf("%s%s ◀◀\n", toPrint, n.Name())
 	} else {
-		toPrint = fmt.Sprintf("%s%s\n", toPrint, Map[n.NodeType])
+		toPrint = fmt.Sprintf("%s%s\n", toPrint, n.Name())
 	}
+	*index++
 	if n.IsCondition() {
-		toPrint = fmt.Sprintf("%s%s", toPrint, n.YesNode.print(newIndent, false, false))
-		toPrint = fmt.Sprintf("%s%s", toPrint, n.NoNode.print(newIndent, false, true))
+		toPrint = fmt.Sprintf("%s%s", toPrint, n.YesNode.print(newIndent, false, false, index, marked))
+		toPrint = fmt.Sprintf("%s%s", toPrint, n.NoNode.print(newIndent, false, true, index, marked))
 	}
 	return toPrint
 }
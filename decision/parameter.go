@@ -0,0 +1,58 @@
+package decision
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Parameter describes the values a parameterized Condition's parameter can
+// have. Values are rounded to the nearest multiple of Precision, so small
+// changes don't create a new decision tree, and mutations change them by up
+// to MaxNudge.
+type Parameter struct {
+	Min, Max, MaxNudge, Precision float64
+}
+
+// random returns a random parameter value
+func (p Parameter) random(r *rand.Rand) float64 {
+	return p.bound(p.Min + r.Float64()*(p.Max-p.Min))
+}
+
+// nudge returns a value changed from the given one by up to MaxNudge
+func (p Parameter) nudge(value float64, r *rand.Rand) float64 {
+	return p.bound(value + p.MaxNudge - r.Float64()*p.MaxNudge*2.0)
+}
+
+// bound rounds a value to the parameter's precision, within its range
+func (p Parameter) bound(value float64) float64 {
+	// dividing by the number of steps per unit avoids floating point noise
+	// like 0.30000000000000004 that multiplying by the precision leaves
+	steps := 1 / p.Precision
+	rounded := math.Round(value*steps) / steps
+	return math.Min(math.Max(rounded, p.Min), p.Max)
+}
+
+// HasParam returns true if the Node is a Condition with a parameter
+func (n *Node) HasParam() bool {
+	condition, ok := n.NodeType.(Condition)
+	if !ok {
+		return false
+	}
+	_, ok = Parameters[condition]
+	return ok
+}
+
+// setCondition sets the Node's Condition, with a random parameter if it
+// takes one
+func (n *Node) setCondition(condition Condition, r *rand.Rand) {
+	n.NodeType = condition
+	n.Param = 0
+	if parameter, ok := Parameters[condition]; ok {
+		n.Param = parameter.random(r)
+	}
+}
+
+// nudgeParam changes the Node's parameter by a small random amount
+func (n *Node) nudgeParam(r *rand.Rand) {
+	n.Param = Parameters[n.NodeType.(Condition)].nudge(n.Param, r)
+}
@@ -0,0 +1,108 @@
+package decision
+
+import "math/rand"
+
+// Checker checks whether Conditions are true when choosing an action
+type Checker interface {
+	// IsConditionTrue returns whether a Condition holds, given its parameter
+	// (if it takes one)
+	IsConditionTrue(condition Condition, param float64, r *rand.Rand) bool
+}
+
+// instruction is a single Node of a compiled Program. A Condition's yes
+// branch always starts at the next instruction, and its no branch at the
+// instruction given by no. Actions have no branches, so no is -1.
+type instruction struct {
+	param    float64
+	no       int32
+	nodeType int32 // the Condition or Action
+}
+
+// Program is a decision tree compiled to a flat list of instructions, in the
+// same order as the tree's nodes are printed. Running it chooses the Action
+// reached by checking each Condition to decide which branch of the tree to
+// follow, without recursion or type switches on NodeType.
+//
+// A Program never changes once compiled, so it can be shared by every
+// organism using the same tree. Each run records its path and visit counts
+// in the organism's own Trace.
+type Program struct {
+	instructions []instruction
+}
+
+// Compile returns a Program that chooses the same Action as the Node
+func Compile(n *Node) *Program {
+	p := &Program{instructions: make([]instruction, len(n.getNodes()))}
+	p.compile(n, 0)
+	return p
+}
+
+// compile writes the instructions for a Node and its children starting at
+// the given index, and returns the index after them
+func (p *Program) compile(n *Node, index int) int {
+	if n.IsAction() {
+		p.instructions[index] = instruction{no: -1, nodeType: int32(n.NodeType.(Action))}
+		return index + 1
+	}
+	noIndex := p.compile(n.YesNode, index+1)
+	p.instructions[index] = instruction{
+		param:    n.Param,
+		no:       int32(noIndex),
+		nodeType: int32(n.NodeType.(Condition)),
+	}
+	return p.compile(n.NoNode, noIndex)
+}
+
+// Run follows the Program from its first instruction until reaching an
+// Action, recording the path it takes and counting each visit in the Trace
+func (p *Program) Run(c Checker, r *rand.Rand, t *Trace) Action {
+	t.Path = t.Path[:0]
+	i := int32(0)
+	for {
+		t.Path = append(t.Path, int(i))
+		t.Visits[i]++
+
+		in := &p.instructions[i]
+		if in.no < 0 {
+			return Action(in.nodeType)
+		}
+		if c.IsConditionTrue(Condition(in.nodeType), in.param, r) {
+			i++
+		} else {
+			i = in.no
+		}
+	}
+}
+
+// Len returns the number of instructions in the Program
+func (p *Program) Len() int {
+	return len(p.instructions)
+}
+
+// Trace records how a single organism has run a shared Program: how many
+// times it has visited each node, and the path it took on its last run. Both
+// refer to nodes by index, in the order their lines are printed by Print.
+type Trace struct {
+	Visits []int
+	Path   []int // changes on the next run
+}
+
+// NewTrace returns an empty Trace for a Program compiled from the Node
+func NewTrace(n *Node) *Trace {
+	return &Trace{Visits: make([]int, len(n.getNodes()))}
+}
+
+// Copy returns an identical Trace
+func (t *Trace) Copy() *Trace {
+	return &Trace{
+		Visits: append([]int{}, t.Visits...),
+		Path:   append([]int{}, t.Path...),
+	}
+}
+
+// ResetVisits sets the Trace's visit counts to 0
+func (t *Trace) ResetVisits() {
+	for i := range t.Visits {
+		t.Visits[i] = 0
+	}
+}
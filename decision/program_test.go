@@ -0,0 +1,105 @@
+package decision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+// bitsChecker decides whether each Condition is true from a random set of
+// bits, so the same Condition and parameter always have the same value
+type bitsChecker uint64
+
+func (b bitsChecker) IsConditionTrue(condition Condition, param float64, _ *rand.Rand) bool {
+	return b>>((uint(condition)+uint(param*10))%64)&1 == 1
+}
+
+// choose is a read-only reference for Program.Run. It walks the Node and its
+// children, checking each Condition to decide which branch to follow, and
+// returns the Action it reaches. visit, if given, is called with the index of
+// each node reached, in the order the tree is printed.
+func choose(n *Node, c Checker, r *rand.Rand, visit func(index int)) Action {
+	for index := 0; ; {
+		if visit != nil {
+			visit(index)
+		}
+		if n.IsAction() {
+			return n.NodeType.(Action)
+		}
+		if c.IsConditionTrue(n.NodeType.(Condition), n.Param, r) {
+			n, index = n.YesNode, index+1
+		} else {
+			n, index = n.NoNode, index+1+n.YesNode.size
+		}
+	}
+}
+
+func randomTrees(count int, r *rand.Rand) []*Tree {
+	globals := config.Globals{MaxDecisionTreeSize: 32, MemoryFlags: MaxMemoryFlags}
+	trees := make([]*Tree, count)
+	for i := range trees {
+		trees[i] = TreeFromAction(GetRandomAction(&globals, r))
+		for mutations := 0; mutations < 30; mutations++ {
+			trees[i] = MutateTree(trees[i], &globals, r)
+		}
+	}
+	return trees
+}
+
+func TestProgramMatchesTree(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	for _, tree := range randomTrees(200, r) {
+		program := Compile(tree.Node)
+		trace := NewTrace(tree.Node)
+		assert.Equal(t, tree.Size(), program.Len())
+
+		visits := make([]int, tree.Size())
+		var path []int
+		for trial := 0; trial < 50; trial++ {
+			checker := bitsChecker(r.Uint64())
+			path = path[:0]
+			action := choose(tree.Node, checker, r, func(index int) {
+				path = append(path, index)
+				visits[index]++
+			})
+			assert.Equal(t, action, program.Run(checker, r, trace), tree.String())
+			assert.Equal(t, path, trace.Path, tree.String())
+		}
+		assert.Equal(t, visits, trace.Visits, tree.String())
+	}
+}
+
+// benchmarkTrees is roughly the number of organisms deciding each cycle in a
+// full simulation, so trees don't all fit in the cache
+const benchmarkTrees = 20000
+
+// BenchmarkChoose walks each tree's nodes as organisms did before trees were
+// compiled
+func BenchmarkChoose(b *testing.B) {
+	r := rand.New(rand.NewSource(10))
+	trees := randomTrees(benchmarkTrees, r)
+	checker := bitsChecker(r.Uint64())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		choose(trees[i%len(trees)].Node, checker, r, nil)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	r := rand.New(rand.NewSource(10))
+	trees := randomTrees(benchmarkTrees, r)
+	programs := make([]*Program, len(trees))
+	traces := make([]*Trace, len(trees))
+	for i, tree := range trees {
+		programs[i] = Compile(tree.Node)
+		traces[i] = NewTrace(tree.Node)
+	}
+	checker := bitsChecker(r.Uint64())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		programs[i%len(programs)].Run(checker, r, traces[i%len(traces)])
+	}
+}
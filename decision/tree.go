@@ -6,7 +6,8 @@ import (
 	"github.com/Zebbeni/protozoa/config"
 )
 
-// Tree is a Node with info to track its success as a top-level decision tree
+// Tree is a Node at the top of a decision tree, identified by its serialized
+// nodes
 type Tree struct {
 	ID string
 	*Node
@@ -22,7 +23,6 @@ func TreeFromAction(action Action) *Tree {
 }
 
 // CopyTree returns a new, identical decision tree
-// Includes current stats as well if copyHistory=true
 func (t *Tree) CopyTree() *Tree {
 	tree := &Tree{
 		ID:   t.ID,
@@ -32,51 +32,75 @@ func (t *Tree) CopyTree() *Tree {
 }
 
 // MutateTree copies a root Tree, makes changes to the full tree, and returns
-func MutateTree(original *Tree) *Tree {
+// it. The number of changes made is drawn from the configured distribution,
+// and each is made by an operator chosen according to the configured weights
+// (or, if none are set, by changing a single node). Some mutations (see
+// PruneMutationChance) instead return the simplified tree, with any
+// unreachable or redundant nodes pruned.
+func MutateTree(original *Tree, g *config.Globals, r *rand.Rand) *Tree {
+	if g.PruneMutationChance > 0 && r.Float64() < g.PruneMutationChance {
+		return original.Simplify()
+	}
 	tree := original.CopyTree()
-	tree.mutate()
+	weighted := hasMutationWeights(g)
+	for i, count := 0, mutationCount(g, r); i < count; i++ {
+		if weighted {
+			tree.mutateWeighted(g, r)
+		} else {
+			tree.mutate(g, r)
+		}
+	}
+	tree.refresh()
 	return tree
 }
 
+// refresh updates the tree's size and ID after changes
+func (t *Tree) refresh() {
+	t.size = t.CalcAndUpdateSize()
+	t.ID = t.Serialize()
+}
+
 // mutate randomly mutates a single node of a tree. This function
 // should only be called on root tree nodes because it uses the tree size.
-func (t *Tree) mutate() {
+func (t *Tree) mutate(g *config.Globals, r *rand.Rand) {
 	// pick a random t anywhere in the decision tree
 	allSubNodes := t.getNodes()
-	node := allSubNodes[rand.Intn(len(allSubNodes))]
+	node := allSubNodes[r.Intn(len(allSubNodes))]
 
-	maxTreeSize := config.MaxDecisionTreeSize()
+	maxTreeSize := g.MaxDecisionTreeSize
 
 	if node.IsAction() {
-		if rand.Intn(2) == 0 && t.size < maxTreeSize-1 {
+		if r.Intn(2) == 0 && t.size < maxTreeSize-1 {
 			// convert action to condition + 2 actions
 			originalAction := node.NodeType.(Action)
-			node.NodeType = GetRandomCondition()
-			if rand.Intn(2) == 0 {
-				node.YesNode = NodeFromAction(GetRandomAction())
+			node.setCondition(GetRandomCondition(g, r), r)
+			if r.Intn(2) == 0 {
+				node.YesNode = NodeFromAction(GetRandomAction(g, r))
 				node.NoNode = NodeFromAction(originalAction)
 			} else {
 				node.YesNode = NodeFromAction(originalAction)
-				node.NoNode = NodeFromAction(GetRandomAction())
+				node.NoNode = NodeFromAction(GetRandomAction(g, r))
 			}
 		} else {
 			// change action type
-			node.NodeType = GetRandomAction()
+			node.NodeType = GetRandomAction(g, r)
 		}
 	} else {
-		if rand.Intn(2) == 0 {
+		if r.Intn(2) == 0 {
 			// convert condition to action (simplify)
-			node.NodeType = GetRandomAction()
+			node.NodeType = GetRandomAction(g, r)
 			node.YesNode = nil
 			node.NoNode = nil
+		} else if node.HasParam() && r.Intn(2) == 0 {
+			// nudge condition parameter
+			node.nudgeParam(r)
 		} else {
 			// change condition type
-			node.NodeType = GetRandomCondition()
+			node.setCondition(GetRandomCondition(g, r), r)
 		}
 	}
 
 	t.size = t.CalcAndUpdateSize()
-	t.ResetUsedLastCycle()
 }
 
 func (t *Tree) Size() int {
@@ -85,5 +109,16 @@ func (t *Tree) Size() int {
 
 // Print prints the full tree structure
 func (t *Tree) Print() string {
-	return t.print("", true, false)
+	return t.PrintPath(nil)
+}
+
+// PrintPath prints the full tree structure, marking the nodes on a path of
+// node indexes (see Trace) with ◀◀
+func (t *Tree) PrintPath(path []int) string {
+	marked := make(map[int]bool, len(path))
+	for _, index := range path {
+		marked[index] = true
+	}
+	index := 0
+	return t.print("", true, false, &index, marked)
 }
@@ -10,9 +10,7 @@ import (
 )
 
 func TestMutateAndRegisterTree(t *testing.T) {
-	globals := config.GetDefaultGlobals()
-	config.SetGlobals(&globals)
-	rand.Seed(2)
+	globals := config.Globals{MaxDecisionTreeSize: 32}
 	// Test simple decision tree
 	node := TreeFromAction(ActEat)
 	expectedID := "02"
@@ -20,18 +18,28 @@ func TestMutateAndRegisterTree(t *testing.T) {
 	assert.Equal(t, expectedID, node.ID, "Unexpected Tree ID")
 	assert.Equal(t, expectedPrint, node.Print())
 	// Test effect of single mutation
-	mutated := MutateTree(node)
-	expectedID = "200602"
+	mutated := MutateTree(node, &globals, rand.New(rand.NewSource(45)))
+	expectedID = "170602"
 	expectedPrint = "If Organism Right\n├─Turn Right\n└─Eat\n"
 	assert.Equal(t, expectedID, mutated.ID, "Unexpected Tree ID after first mutate")
 	assert.Equal(t, expectedPrint, mutated.Print())
 	// Register and verify in tree library
 	lib := NewLibrary()
 	lib.RegisterAndReturnTree(mutated)
-	libTree, ok := lib[expectedID]
+	entry, ok := lib[expectedID]
 	if !ok {
 		t.Errorf("Mutated Tree with with expected ID %s not found after registering", expectedID)
 	} else {
-		assert.Equal(t, expectedPrint, libTree.Print())
+		assert.Equal(t, expectedPrint, entry.Tree.Print())
 	}
 }
+
+func TestPrintPath(t *testing.T) {
+	tree, err := ParseTree("(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "If Food Ahead ◀◀\n├─Eat\n└─If Can Move Ahead ◀◀\n  ├─Move Ahead\n  └─Turn Left ◀◀\n",
+		tree.PrintPath([]int{0, 2, 4}))
+	assert.NotContains(t, tree.Print(), "◀◀")
+}
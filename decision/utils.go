@@ -2,6 +2,8 @@ package decision
 
 import (
 	"math/rand"
+
+	"github.com/Zebbeni/protozoa/config"
 )
 
 // CalcAndUpdateSize returns the total number of nodes descending from this root node (including itself)
@@ -16,14 +18,62 @@ func (n *Node) CalcAndUpdateSize() int {
 	return n.size
 }
 
-// GetRandomCondition returns a random Condition from the Conditions array
-func GetRandomCondition() Condition {
-	return Conditions[rand.Intn(len(Conditions))]
+// GetRandomCondition returns a random Condition from the Conditions array,
+// or a check of one of the configured number of memory flags
+func GetRandomCondition(g *config.Globals, r *rand.Rand) Condition {
+	flags := MemoryFlags(g)
+	choice := r.Intn(len(Conditions) + flags)
+	if choice < len(Conditions) {
+		return Conditions[choice]
+	}
+	return FlagConditions[choice-len(Conditions)]
 }
 
-// GetRandomAction returns a random Action from the Actions array
-func GetRandomAction() Action {
-	return Actions[rand.Intn(len(Actions))]
+// GetRandomAction returns a random Action from the Actions array, or a change
+// to one of the configured number of memory flags
+func GetRandomAction(g *config.Globals, r *rand.Rand) Action {
+	flags := MemoryFlags(g)
+	choice := r.Intn(len(Actions) + 2*flags)
+	switch {
+	case choice < len(Actions):
+		return Actions[choice]
+	case choice < len(Actions)+flags:
+		return FlagSetActions[choice-len(Actions)]
+	}
+	return FlagClearActions[choice-len(Actions)-flags]
+}
+
+// MemoryFlags returns the configured number of memory flags, limited to
+// those available
+func MemoryFlags(g *config.Globals) int {
+	if g.MemoryFlags < 0 {
+		return 0
+	}
+	if g.MemoryFlags > MaxMemoryFlags {
+		return MaxMemoryFlags
+	}
+	return g.MemoryFlags
+}
+
+// Flag returns the memory flag an Action changes and whether it sets (rather
+// than clears) it. ok is false if the Action doesn't change a memory flag.
+func (a Action) Flag() (flag int, set, ok bool) {
+	switch {
+	case a >= ActSetFlag0 && a <= ActSetFlag3:
+		return int(a - ActSetFlag0), true, true
+	case a >= ActClearFlag0 && a <= ActClearFlag3:
+		return int(a - ActClearFlag0), false, true
+	}
+	return 0, false, false
+}
+
+// Flag returns the memory flag a Condition checks. ok is false if the
+// Condition doesn't check a memory flag.
+func (c Condition) Flag() (flag int, ok bool) {
+	if c >= IsFlag0Set && c <= IsFlag3Set {
+		return int(c - IsFlag0Set), true
+	}
+	return 0, false
 }
 
 // isAction returns true if the object passed in is an Action
@@ -0,0 +1,185 @@
+package genealogy
+
+import "sort"
+
+// NoParent is the parent ID recorded for organisms that weren't born to
+// another, eg. random, seeded and bank organisms
+const NoParent = -1
+
+// NoKiller is the killer ID recorded for organisms not killed by another
+const NoKiller = -1
+
+// Alive is the death cycle recorded for organisms still living
+const Alive = -1
+
+// Cause is what an organism died of
+type Cause uint8
+
+const (
+	// CauseNone is recorded for organisms still living
+	CauseNone Cause = iota
+	// CauseStarvation is the metabolic cost of an organism's brain and
+	// actions outweighing what it eats and makes
+	CauseStarvation
+	// CauseAttack is being attacked by another organism
+	CauseAttack
+	// CausePh is the cost of living outside an organism's ph tolerance
+	CausePh
+	// CauseSpawning is the health given up to spawn a child
+	CauseSpawning
+	// CauseFeeding is being fed a negative amount by another organism
+	CauseFeeding
+)
+
+// Causes lists every cause of death, in the order they're reported
+var Causes = []Cause{CauseStarvation, CauseAttack, CausePh, CauseSpawning, CauseFeeding}
+
+var causeNames = map[Cause]string{
+	CauseNone:       "none",
+	CauseStarvation: "starvation",
+	CauseAttack:     "attack",
+	CausePh:         "ph stress",
+	CauseSpawning:   "spawning",
+	CauseFeeding:    "feeding",
+}
+
+func (c Cause) String() string {
+	return causeNames[c]
+}
+
+// Record is the genealogy of a single organism. Fields are kept small since
+// a record is stored for every organism a run creates.
+type Record struct {
+	Parent int32 // the ID of the organism it was born to, or NoParent
+	Born   int32 // the cycle it was born
+	Died   int32 // the cycle it died, or Alive
+	Cause  Cause // what it died of, or CauseNone
+	Killer int32 // the ID of the organism that killed it, or NoKiller
+	// Mutated is set if its brain (decision tree or network) differed from
+	// its parent's at birth, by mutation or crossover
+	Mutated bool
+}
+
+// IsAlive returns true if the organism hasn't died
+func (r Record) IsAlive() bool {
+	return r.Died == Alive
+}
+
+// Genealogy records the parent, birth and death of every organism by ID.
+// Once it holds more than its record limit it prunes extinct branches,
+// keeping only living organisms and their ancestors.
+type Genealogy struct {
+	records map[int32]Record
+	limit   int
+	pruneAt int
+}
+
+// New returns an empty Genealogy holding up to limit records before pruning
+// extinct branches (or any number if limit is 0)
+func New(limit int) *Genealogy {
+	return FromRecords(nil, limit)
+}
+
+// FromRecords returns a Genealogy holding previously-saved records
+func FromRecords(records map[int32]Record, limit int) *Genealogy {
+	if records == nil {
+		records = make(map[int32]Record)
+	}
+	return &Genealogy{records: records, limit: limit, pruneAt: limit}
+}
+
+// Records returns every record held, by organism ID. The map must not be
+// modified.
+func (g *Genealogy) Records() map[int32]Record {
+	return g.records
+}
+
+// Len returns the number of records held
+func (g *Genealogy) Len() int {
+	return len(g.records)
+}
+
+// Get returns the record of an organism, and false if there is none because
+// its branch was pruned or it was never recorded
+func (g *Genealogy) Get(id int) (Record, bool) {
+	record, ok := g.records[int32(id)]
+	return record, ok
+}
+
+// Born records the birth of an organism to a parent (or NoParent) at a cycle,
+// pruning extinct branches if the record limit is passed. Organisms created
+// before the first cycle are recorded as born in cycle 0.
+func (g *Genealogy) Born(id, parent, cycle int, mutated bool) {
+	if cycle < 0 {
+		cycle = 0
+	}
+	g.records[int32(id)] = Record{
+		Parent:  int32(parent),
+		Born:    int32(cycle),
+		Died:    Alive,
+		Killer:  NoKiller,
+		Mutated: mutated,
+	}
+	if g.limit > 0 && len(g.records) > g.pruneAt {
+		g.Prune()
+		// wait until the records double again if the surviving tree is
+		// already past the limit, so pruning isn't repeated every birth
+		if g.pruneAt = 2 * len(g.records); g.pruneAt < g.limit {
+			g.pruneAt = g.limit
+		}
+	}
+}
+
+// Died records the death of an organism at a cycle, if it's recorded, and
+// the ID of the organism that killed it, if any
+func (g *Genealogy) Died(id, cycle int, cause Cause, killer int) {
+	if record, ok := g.records[int32(id)]; ok {
+		record.Died, record.Cause, record.Killer = int32(cycle), cause, int32(killer)
+		g.records[int32(id)] = record
+	}
+}
+
+// Prune removes the records of dead organisms with no living descendants,
+// and returns how many were removed
+func (g *Genealogy) Prune() int {
+	surviving := g.surviving()
+	pruned := 0
+	for id := range g.records {
+		if !surviving[id] {
+			delete(g.records, id)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// sortedIDs returns the IDs of all records from oldest to newest
+func (g *Genealogy) sortedIDs() []int32 {
+	ids := make([]int32, 0, len(g.records))
+	for id := range g.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// surviving returns the IDs of living organisms and their recorded
+// ancestors. Organisms are always born after their parents and given higher
+// IDs, so walking from newest to oldest marks each parent after its children.
+func (g *Genealogy) surviving() map[int32]bool {
+	surviving := make(map[int32]bool)
+	ids := g.sortedIDs()
+	for i := len(ids) - 1; i >= 0; i-- {
+		id := ids[i]
+		record := g.records[id]
+		if record.IsAlive() {
+			surviving[id] = true
+		}
+		if surviving[id] && record.Parent != NoParent {
+			if _, ok := g.records[record.Parent]; ok {
+				surviving[record.Parent] = true
+			}
+		}
+	}
+	return surviving
+}
@@ -0,0 +1,90 @@
+package genealogy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGenealogy returns a Genealogy where 0 had children 2 and 3 (mutated),
+// 2 had child 4, 1 had child 5, and only 3, 4 and 1 are alive
+func testGenealogy(limit int) *Genealogy {
+	g := New(limit)
+	g.Born(0, NoParent, 0, false)
+	g.Born(1, NoParent, 0, false)
+	g.Born(2, 0, 10, false)
+	g.Born(3, 0, 15, true)
+	g.Born(4, 2, 30, true)
+	g.Born(5, 1, 40, false)
+	g.Died(0, 20, CauseStarvation, NoKiller)
+	g.Died(2, 35, CauseAttack, 3)
+	g.Died(5, 45, CauseSpawning, NoKiller)
+	return g
+}
+
+func TestBornAndDied(t *testing.T) {
+	g := testGenealogy(0)
+	assert.Equal(t, 6, g.Len())
+
+	record, ok := g.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, Record{Parent: 0, Born: 10, Died: 35, Cause: CauseAttack, Killer: 3}, record)
+	assert.False(t, record.IsAlive())
+	record, _ = g.Get(3)
+	assert.True(t, record.IsAlive())
+	assert.Equal(t, CauseNone, record.Cause)
+	assert.Equal(t, int32(NoKiller), record.Killer)
+	assert.True(t, record.Mutated)
+
+	// deaths of organisms never recorded are ignored
+	g.Died(9, 50, CauseStarvation, NoKiller)
+	_, ok = g.Get(9)
+	assert.False(t, ok)
+}
+
+func TestPrune(t *testing.T) {
+	g := testGenealogy(0)
+	assert.Equal(t, 1, g.Prune())
+	_, ok := g.Get(5)
+	assert.False(t, ok)
+	_, ok = g.Get(2)
+	assert.True(t, ok, "dead ancestors of living organisms are kept")
+	assert.Equal(t, 0, g.Prune())
+}
+
+func TestPruneAtLimit(t *testing.T) {
+	g := testGenealogy(6)
+	assert.Equal(t, 6, g.Len())
+	g.Born(6, 3, 50, false)
+	assert.Equal(t, 6, g.Len(), "extinct branch pruned once past the limit")
+	_, ok := g.Get(5)
+	assert.False(t, ok)
+}
+
+func TestWriteNewick(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, testGenealogy(0).WriteNewick(&buffer))
+	assert.Equal(t, "(((4:20[&&NHX:tree_mutation=1])2:10,3:15[&&NHX:tree_mutation=1])0:0,1:0);\n", buffer.String())
+
+	buffer.Reset()
+	assert.NoError(t, New(0).WriteNewick(&buffer))
+	assert.Equal(t, "();\n", buffer.String())
+}
+
+func TestMortality(t *testing.T) {
+	m := NewMortality()
+	m.Record(0, CauseStarvation)
+	m.Record(0, CauseStarvation)
+	m.Record(0, CauseAttack)
+	m.Record(1, CausePh)
+	assert.Equal(t, map[Cause]int{CauseStarvation: 2, CauseAttack: 1, CausePh: 1}, m.Total)
+	assert.Equal(t, map[Cause]int{CauseStarvation: 2, CauseAttack: 1}, m.Lineage(0))
+	assert.Equal(t, 3, Sum(m.Lineage(0)))
+	assert.Equal(t, 0, Sum(m.Lineage(2)))
+	assert.Equal(t, "ph stress", CausePh.String())
+	assert.Equal(t, "starvation 2 (67%), attack 1 (33%)", Describe(m.Lineage(0)))
+	assert.Equal(t, "", Describe(nil))
+	assert.Equal(t, []int{0, 1}, m.DeadliestLineages(5))
+	assert.Equal(t, []int{0}, m.DeadliestLineages(1))
+}
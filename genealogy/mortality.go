@@ -0,0 +1,86 @@
+package genealogy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mortality counts deaths by cause, across the whole population and within
+// each original ancestor's lineage
+type Mortality struct {
+	Total    map[Cause]int
+	Lineages map[int]map[Cause]int // by original ancestor ID
+}
+
+// NewMortality returns a Mortality with no deaths counted
+func NewMortality() *Mortality {
+	return &Mortality{
+		Total:    make(map[Cause]int),
+		Lineages: make(map[int]map[Cause]int),
+	}
+}
+
+// Record counts the death of an organism descending from an original
+// ancestor
+func (m *Mortality) Record(ancestorID int, cause Cause) {
+	// empty maps aren't saved in snapshots, so may need creating again
+	if m.Total == nil {
+		*m = *NewMortality()
+	}
+	m.Total[cause]++
+	lineage, ok := m.Lineages[ancestorID]
+	if !ok {
+		lineage = make(map[Cause]int)
+		m.Lineages[ancestorID] = lineage
+	}
+	lineage[cause]++
+}
+
+// Lineage returns the deaths by cause of an original ancestor's lineage. The
+// map must not be modified.
+func (m *Mortality) Lineage(ancestorID int) map[Cause]int {
+	return m.Lineages[ancestorID]
+}
+
+// Sum returns the total number of deaths in a set of counts by cause
+func Sum(counts map[Cause]int) int {
+	sum := 0
+	for _, count := range counts {
+		sum += count
+	}
+	return sum
+}
+
+// Describe lists the deaths counted for each cause, in the order of Causes
+// and skipping causes with none, eg. "starvation 12 (75%), attack 4 (25%)"
+func Describe(counts map[Cause]int) string {
+	total := Sum(counts)
+	descriptions := make([]string, 0, len(Causes))
+	for _, cause := range Causes {
+		if count := counts[cause]; count > 0 {
+			descriptions = append(descriptions, fmt.Sprintf("%s %d (%.0f%%)", cause, count, 100*float64(count)/float64(total)))
+		}
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// DeadliestLineages returns the IDs of up to n original ancestors whose
+// lineages have the most deaths, from most to fewest
+func (m *Mortality) DeadliestLineages(n int) []int {
+	ids := make([]int, 0, len(m.Lineages))
+	for id := range m.Lineages {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		deathsI, deathsJ := Sum(m.Lineages[ids[i]]), Sum(m.Lineages[ids[j]])
+		if deathsI != deathsJ {
+			return deathsI > deathsJ
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
@@ -0,0 +1,70 @@
+package genealogy
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// WriteNewick writes the surviving tree of life to w in Newick format: every
+// living organism and its recorded ancestors, labelled by ID, each with a
+// branch length of the cycles between its parent's birth and its own (or
+// since cycle 0 for organisms with no recorded parent). Lineages with no
+// recorded parent are joined at an unlabelled root. Branches where the brain
+// changed at birth are marked with an NHX comment, eg.
+//
+//	((2:40,5:61[&&NHX:tree_mutation=1])0:0,1:0);
+func (g *Genealogy) WriteNewick(w io.Writer) error {
+	surviving := g.surviving()
+	children := make(map[int32][]int32)
+	var roots []int32
+	for _, id := range g.sortedIDs() {
+		if !surviving[id] {
+			continue
+		}
+		if record := g.records[id]; g.hasParent(record) {
+			children[record.Parent] = append(children[record.Parent], id)
+		} else {
+			roots = append(roots, id)
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	writer.WriteByte('(')
+	for i, id := range roots {
+		if i > 0 {
+			writer.WriteByte(',')
+		}
+		g.writeNewickNode(writer, id, 0, children)
+	}
+	writer.WriteString(");\n")
+	return writer.Flush()
+}
+
+// writeNewickNode writes an organism's subtree, given the cycle its parent
+// was born
+func (g *Genealogy) writeNewickNode(writer *bufio.Writer, id int32, parentBorn int32, children map[int32][]int32) {
+	record := g.records[id]
+	if kids := children[id]; len(kids) > 0 {
+		writer.WriteByte('(')
+		for i, child := range kids {
+			if i > 0 {
+				writer.WriteByte(',')
+			}
+			g.writeNewickNode(writer, child, record.Born, children)
+		}
+		writer.WriteByte(')')
+	}
+	writer.WriteString(strconv.Itoa(int(id)))
+	writer.WriteByte(':')
+	writer.WriteString(strconv.Itoa(int(record.Born - parentBorn)))
+	if record.Mutated && g.hasParent(record) {
+		writer.WriteString("[&&NHX:tree_mutation=1]")
+	}
+}
+
+// hasParent returns true if the organism's parent is recorded
+func (g *Genealogy) hasParent(record Record) bool {
+	_, ok := g.records[record.Parent]
+	return ok && record.Parent != NoParent
+}
@@ -25,7 +25,10 @@ func main() {
 		globals = &p
 	}
 
-	config.SetGlobals(globals)
+	if opts.SweepFile != "" {
+		runner.RunSweep(opts, globals)
+		os.Exit(0)
+	}
 
-	runner.RunSimulation(opts)
+	runner.RunSimulation(opts, globals)
 }
@@ -11,13 +11,17 @@ import (
 // EnvironmentManager contains an image
 type EnvironmentManager struct {
 	api           environment.API
+	globals       *c.Globals
+	random        *rand.Rand
 	phMap         [][][]float64
 	updatedPoints map[string]utils.Point
 }
 
-func NewEnvironmentManager(api environment.API) *EnvironmentManager {
+func NewEnvironmentManager(api environment.API, g *c.Globals, r *rand.Rand) *EnvironmentManager {
 	manager := &EnvironmentManager{
 		api:           api,
+		globals:       g,
+		random:        r,
 		updatedPoints: make(map[string]utils.Point),
 	}
 
@@ -27,14 +31,14 @@ func NewEnvironmentManager(api environment.API) *EnvironmentManager {
 }
 
 func (m *EnvironmentManager) initializePhMap() {
-	gridW, gridH := c.GridUnitsWide(), c.GridUnitsHigh()
+	gridW, gridH := m.globals.GridUnitsWide, m.globals.GridUnitsHigh
 	m.phMap = [][][]float64{make([][]float64, gridW), make([][]float64, gridW)}
 	for x := 0; x < gridW; x++ {
 		m.phMap[0][x] = make([]float64, gridH)
 		m.phMap[1][x] = make([]float64, gridH)
 		for y := 0; y < gridH; y++ {
 			// initialize with random values
-			val := rand.Float64()*(c.MaxInitialPh()-c.MinInitialPh()) + c.MinInitialPh()
+			val := m.random.Float64()*(m.globals.MaxInitialPh-m.globals.MinInitialPh) + m.globals.MinInitialPh
 			m.phMap[0][x][y] = val
 			m.phMap[1][x][y] = val
 		}
@@ -74,12 +78,12 @@ func (m *EnvironmentManager) AddPhChangeAtPoint(point utils.Point, change float6
 
 func (m *EnvironmentManager) setPhAtPoint(point utils.Point, val float64) {
 	prevPh := m.phMap[m.getPreviousIndex()][point.X][point.Y]
-	newPh := math.Max(math.Min(val, c.MaxPh()), c.MinPh())
+	newPh := math.Max(math.Min(val, m.globals.MaxPh), m.globals.MinPh)
 
 	m.phMap[m.getCurrentIndex()][point.X][point.Y] = newPh
 
 	// only flag a worthwhile update if change is passed the difference threshhold
-	if int(prevPh/c.PhIncrementToDisplay()) != int(newPh/c.PhIncrementToDisplay()) {
+	if int(prevPh/m.globals.PhIncrementToDisplay) != int(newPh/m.globals.PhIncrementToDisplay) {
 		m.addUpdatedPoint(point)
 	}
 }
@@ -101,9 +105,9 @@ func (m *EnvironmentManager) addUpdatedPoint(point utils.Point) {
 // simulate diffusion of ph across the environment by adjusting each
 // ph value toward its neighbors' values
 func (m *EnvironmentManager) diffusePhLevels() {
-	gridW, gridH := c.GridUnitsWide(), c.GridUnitsHigh()
+	gridW, gridH := m.globals.GridUnitsWide, m.globals.GridUnitsHigh
 	prev := m.getPreviousIndex()
-	diffFactor := c.PhDiffuseFactor()
+	diffFactor := m.globals.PhDiffuseFactor
 	// set each value in the current phMap to its value in the previous phMap, plus
 	// the average difference between itself and its N,S,E,W neighbors (times the
 	// diffusion factor provided by the config)
@@ -12,23 +12,41 @@ import (
 // FoodManager contains 2D array of all food values
 type FoodManager struct {
 	initialized bool
+	globals     *config.Globals
+	random      *rand.Rand
 
 	updatedPoints map[string]utils.Point // a map of points updated since the previous cycle
 
 	Items map[string]*food.Item
+	grid  [][]*food.Item // the same Items by location, for fast lookups
 }
 
 // NewFoodManager initializes a new foodItem map of MinFood
-func NewFoodManager() *FoodManager {
+func NewFoodManager(g *config.Globals, r *rand.Rand) *FoodManager {
 	m := &FoodManager{
 		initialized:   false,
+		globals:       g,
+		random:        r,
 		updatedPoints: make(map[string]utils.Point),
 		Items:         make(map[string]*food.Item),
+		grid:          newFoodGrid(g, nil),
 	}
-	m.InitializeFood(config.InitialFood())
+	m.InitializeFood(g.InitialFood)
 	return m
 }
 
+// newFoodGrid returns a grid of the given food items by location
+func newFoodGrid(g *config.Globals, items map[string]*food.Item) [][]*food.Item {
+	grid := make([][]*food.Item, g.GridUnitsWide)
+	for x := range grid {
+		grid[x] = make([]*food.Item, g.GridUnitsHigh)
+	}
+	for _, item := range items {
+		grid[item.Point.X][item.Point.Y] = item
+	}
+	return grid
+}
+
 func (m *FoodManager) InitializeFood(count int) {
 	for i := 0; i < count; i++ {
 		m.AddRandomFoodItem()
@@ -37,7 +55,7 @@ func (m *FoodManager) InitializeFood(count int) {
 
 // Update is called on every cycle and adds new FoodItems at a constant rate
 func (m *FoodManager) Update() {
-	if rand.Float64() < config.ChanceToAddFoodItem() {
+	if m.random.Float64() < m.globals.ChanceToAddFoodItem {
 		m.AddRandomFoodItem()
 	}
 	return
@@ -51,9 +69,9 @@ func (m *FoodManager) FoodCount() int {
 // AddRandomFoodItem attempts to add a FoodItem object to a random location
 // Gives up if first attempt to place food fails.
 func (m *FoodManager) AddRandomFoodItem() {
-	x := rand.Intn(config.GridUnitsWide())
-	y := rand.Intn(config.GridUnitsHigh())
-	value := rand.Intn(config.MaxFoodValue())
+	x := m.random.Intn(m.globals.GridUnitsWide)
+	y := m.random.Intn(m.globals.GridUnitsHigh)
+	value := m.random.Intn(m.globals.MaxFoodValue)
 	point := utils.Point{X: x, Y: y}
 	if added := m.AddFoodAtPoint(point, value); added > 0 {
 		m.addUpdatedPoint(point)
@@ -72,16 +90,18 @@ func (m *FoodManager) AddFoodAtPoint(point utils.Point, value int) int {
 	locationString := point.ToString()
 	item, exists := m.Items[locationString]
 	if !exists {
-		value = int(math.Min(math.Max(0.0, float64(value)), float64(config.MaxFoodValue())))
-		m.Items[locationString] = food.NewItem(point, value)
+		value = int(math.Min(math.Max(0.0, float64(value)), float64(m.globals.MaxFoodValue)))
+		item = food.NewItem(point, value)
+		m.Items[locationString] = item
+		m.grid[point.X][point.Y] = item
 		return value
 	}
 
 	originalValue := item.Value
 	item.Value += value
-	if item.Value > config.MaxFoodValue() {
-		item.Value = config.MaxFoodValue()
-		return config.MaxFoodValue() - originalValue
+	if item.Value > m.globals.MaxFoodValue {
+		item.Value = m.globals.MaxFoodValue
+		return m.globals.MaxFoodValue - originalValue
 	}
 	return value
 }
@@ -105,8 +125,9 @@ func (m *FoodManager) RemoveFoodAtPoint(point utils.Point, value int) int {
 	originalValue := item.Value
 	item.Value -= value
 
-	if item.Value < config.MinFoodValue() {
+	if item.Value < m.globals.MinFoodValue {
 		delete(m.Items, locationString)
+		m.grid[point.X][point.Y] = nil
 	}
 
 	if originalValue >= value {
@@ -118,8 +139,7 @@ func (m *FoodManager) RemoveFoodAtPoint(point utils.Point, value int) int {
 
 // GetFoodAtPoint returns the FoodItem value at a given point (nil if none found)
 func (m *FoodManager) GetFoodAtPoint(point utils.Point) *food.Item {
-	foodItem, _ := m.Items[point.ToString()]
-	return foodItem
+	return m.grid[point.X][point.Y]
 }
 
 // GetFoodItems returns the current list of food items
@@ -3,20 +3,27 @@ package manager
 import (
 	"fmt"
 	"image/color"
+	"log"
 	"math"
+	"math/rand"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/Zebbeni/protozoa/brain"
 	c "github.com/Zebbeni/protozoa/config"
 	d "github.com/Zebbeni/protozoa/decision"
 	"github.com/Zebbeni/protozoa/food"
+	"github.com/Zebbeni/protozoa/genealogy"
 	"github.com/Zebbeni/protozoa/organism"
 	"github.com/Zebbeni/protozoa/utils"
 )
 
 // OrganismManager contains 2D array of booleans showing if organism present
 type OrganismManager struct {
-	api organism.API
+	api     organism.API
+	globals *c.Globals
+	random  *rand.Rand
 
 	organisms             map[int]*organism.Organism
 	organismIDGrid        [][]int
@@ -25,61 +32,188 @@ type OrganismManager struct {
 	organismUpdateOrder []int
 	newOrganismIDs      []int
 
+	// each decision worker has its own random stream, reseeded for every
+	// organism so results don't depend on the number of workers
+	workerRandoms []*rand.Rand
+	workerSources []*utils.RandomSource
+
 	updatedPoints map[string]utils.Point // a map of points updated since the previous cycle
 
 	originalAncestorsSorted []int
 	originalAncestorColors  map[int]color.Color   // all original ancestor IDs with at least one descendant
 	populationHistory       map[int]map[int]int16 // cycle : ancestorId : livingDescendantsCount
 
+	treeLibrary      d.Library  // every decision tree seen in the population, with its stats
+	treePool         brain.Pool // a single shared copy of each decision tree in use
+	lastNewTreeCycle int        // the last cycle a never-before-seen decision tree appeared
+
+	genealogy *genealogy.Genealogy // the parent, birth and death of every organism
+	mortality *genealogy.Mortality // deaths by cause, in total and by lineage
+
+	archive     []*organism.Organism // dead organisms with the most children, used for reseeding
+	reseedCount int
+
 	UpdateDuration, ResolveDuration time.Duration
 }
 
-// NewOrganismManager creates all Organisms and updates grid
-func NewOrganismManager(api organism.API) *OrganismManager {
-	grid := initializeGrid()
+// NewOrganismManager creates all Organisms and updates grid, starting with
+// those described by the population, if given, then those grown from the
+// given genomes, and then InitialOrganisms random ones
+func NewOrganismManager(api organism.API, g *c.Globals, population *organism.Population, genomes []*organism.Genome, r *rand.Rand) *OrganismManager {
+	grid := initializeGrid(g)
 	organisms := make(map[int]*organism.Organism)
 	manager := &OrganismManager{
 		api:                    api,
+		globals:                g,
+		random:                 r,
 		organismIDGrid:         grid,
 		organisms:              organisms,
-		organismUpdateOrder:    make([]int, 0, c.MaxOrganisms()),
+		organismUpdateOrder:    make([]int, 0, g.MaxOrganisms),
 		newOrganismIDs:         make([]int, 0, 100),
 		updatedPoints:          make(map[string]utils.Point),
 		originalAncestorColors: make(map[int]color.Color),
 		populationHistory:      make(map[int]map[int]int16),
+		treeLibrary:            d.NewLibrary(),
+		treePool:               brain.NewPool(),
+		genealogy:              genealogy.New(g.GenealogyRecordLimit),
+		mortality:              genealogy.NewMortality(),
 	}
-	manager.InitializeOrganisms(c.InitialOrganisms())
+	manager.SetWorkerCount(1)
+	if population != nil {
+		manager.SeedOrganisms(population)
+	}
+	manager.SeedGenomes(genomes)
+	manager.InitializeOrganisms(g.InitialOrganisms)
 	return manager
 }
 
+// SetWorkerCount sets the number of goroutines used to choose organism
+// actions each cycle (minimum 1)
+func (m *OrganismManager) SetWorkerCount(count int) {
+	if count < 1 {
+		count = 1
+	}
+	m.workerRandoms = make([]*rand.Rand, count)
+	m.workerSources = make([]*utils.RandomSource, count)
+	for w := 0; w < count; w++ {
+		m.workerRandoms[w], m.workerSources[w] = utils.NewRand(0)
+	}
+}
+
 func (m *OrganismManager) InitializeOrganisms(count int) {
 	for i := 0; i < count; i++ {
 		m.SpawnRandomOrganism()
 	}
 }
 
+// SeedOrganisms creates the organisms in each of the population's groups at
+// random empty locations within the group's region, and logs any group that
+// couldn't all be placed. Each group is shown as a single ancestor.
+func (m *OrganismManager) SeedOrganisms(population *organism.Population) {
+	for _, group := range population.Groups {
+		added, ancestorID := 0, m.totalOrganismsCreated
+		for attempt := 0; added < group.Count && attempt < group.Count*spawnAttemptsPerOrganism; attempt++ {
+			point := group.Region.RandomPoint(m.random)
+			if !m.isGridLocationEmpty(point) {
+				continue
+			}
+			index := m.totalOrganismsCreated
+			o := organism.NewSeeded(index, ancestorID, point, group, m.api, m.globals, m.random)
+			m.registerNewOrganism(o, index, nil)
+			if added == 0 {
+				m.addToOriginalAncestors(o)
+			}
+			added++
+		}
+		if added < group.Count {
+			log.Printf("placed %d of %d organisms in population group %s", added, group.Count, group.Name)
+		}
+	}
+}
+
+// SeedGenomes creates an organism from each genome at a random empty
+// location, and logs any that couldn't be placed. Genomes that shared an
+// original ancestor share the first of them placed as theirs.
+func (m *OrganismManager) SeedGenomes(genomes []*organism.Genome) {
+	ancestorIDs := make(map[int]int)
+	added := 0
+	for _, genome := range genomes {
+		for attempt := 0; attempt < spawnAttemptsPerOrganism; attempt++ {
+			point, found := m.getRandomSpawnLocation()
+			if !found {
+				continue
+			}
+			index := m.totalOrganismsCreated
+			ancestorID, ok := ancestorIDs[genome.AncestorID]
+			if !ok {
+				ancestorID = index
+				ancestorIDs[genome.AncestorID] = index
+			}
+			o := organism.NewFromGenome(index, ancestorID, point, genome, m.api, m.globals, m.random)
+			m.registerNewOrganism(o, index, nil)
+			if !ok {
+				m.addToOriginalAncestors(o)
+			}
+			added++
+			break
+		}
+	}
+	if added < len(genomes) {
+		log.Printf("placed %d of %d organisms from the genome bank", added, len(genomes))
+	}
+}
+
+// Bank returns the genomes of all living organisms at the given cycle
+func (m *OrganismManager) Bank(cycle int) *organism.Bank {
+	ids := make([]int, 0, len(m.organisms))
+	for id := range m.organisms {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	organisms := make([]*organism.Organism, len(ids))
+	for i, id := range ids {
+		organisms[i] = m.organisms[id]
+	}
+	return organism.NewBank(cycle, organisms)
+}
+
 // Update walks through decision tree of each organism and applies the
 // chosen action to the organism, the grid, and the environment
 func (m *OrganismManager) Update() {
-	// FUTURE: do this multi-threaded
 	start := time.Now()
-	for _, id := range m.organismUpdateOrder {
-		m.updateOrganism(m.organisms[id])
-	}
+	m.updateOrganisms()
+	m.recordTreeUsage()
 	m.UpdateDuration = time.Since(start)
 	start = time.Now()
-	for _, id := range m.organismUpdateOrder {
-		m.resolveOrganismAction(m.organisms[id])
+	if m.globals.ActionResolutionMode == c.ResolveSimultaneous {
+		m.resolveSimultaneously()
+	} else {
+		for _, id := range m.organismUpdateOrder {
+			m.resolveOrganismAction(m.organisms[id])
+		}
 	}
 	m.ResolveDuration = time.Since(start)
+	m.reseed()
 	m.updateOrganismOrder()
 	m.updateHistory()
+	m.evictExtinctTrees()
+}
+
+// evictExtinctTrees removes the decision trees that have had no carriers for
+// TreeLibraryEvictionCycles from the library, checking once every population
+// update
+func (m *OrganismManager) evictExtinctTrees() {
+	cycle := m.api.Cycle()
+	if m.globals.TreeLibraryEvictionCycles <= 0 || cycle%m.globals.PopulationUpdateInterval != 0 {
+		return
+	}
+	m.treeLibrary.Evict(cycle, m.globals.TreeLibraryEvictionCycles)
 }
 
 // updateHistory updates the population map for all living organisms
 func (m *OrganismManager) updateHistory() {
 	cycle := m.api.Cycle()
-	if cycle%c.PopulationUpdateInterval() != 0 {
+	if cycle%m.globals.PopulationUpdateInterval != 0 {
 		return
 	}
 
@@ -130,13 +264,13 @@ func (m *OrganismManager) updateOrganismOrder() {
 	m.newOrganismIDs = make([]int, 0, 100)
 }
 
-func initializeGrid() [][]int {
-	grid := make([][]int, c.GridUnitsWide())
-	for r := 0; r < c.GridUnitsWide(); r++ {
-		grid[r] = make([]int, c.GridUnitsHigh())
+func initializeGrid(g *c.Globals) [][]int {
+	grid := make([][]int, g.GridUnitsWide)
+	for r := 0; r < g.GridUnitsWide; r++ {
+		grid[r] = make([]int, g.GridUnitsHigh)
 	}
-	for x := 0; x < c.GridUnitsWide(); x++ {
-		for y := 0; y < c.GridUnitsHigh(); y++ {
+	for x := 0; x < g.GridUnitsWide; x++ {
+		for y := 0; y < g.GridUnitsHigh; y++ {
 			grid[x][y] = -1
 		}
 	}
@@ -159,12 +293,70 @@ func (m *OrganismManager) updateEnvironmentPh(o *organism.Organism) {
 	m.api.AddPhChangeAtPoint(o.Location, o.Traits().PhEffect*o.Size)
 }
 
-func (m *OrganismManager) updateOrganism(o *organism.Organism) {
-	if o.Action() == d.ActAttack {
-		m.addUpdatedPoint(o.Location)
+// updateOrganisms has every organism choose its next action, split across
+// all workers. Choosing actions only reads the world state, so the results
+// are identical no matter how many workers are used.
+func (m *OrganismManager) updateOrganisms() {
+	// attack actions are drawn differently, so redraw any previous attackers
+	for _, id := range m.organismUpdateOrder {
+		if o := m.organisms[id]; o.Action() == d.ActAttack {
+			m.addUpdatedPoint(o.Location)
+		}
+	}
+
+	seed := m.random.Int63()
+	workers := len(m.workerRandoms)
+	if workers == 1 {
+		m.updateOrganismRange(m.organismUpdateOrder, seed, 0)
+		return
+	}
+
+	var wg sync.WaitGroup
+	chunkSize := (len(m.organismUpdateOrder) + workers - 1) / workers
+	for w := 0; w < workers; w++ {
+		first := w * chunkSize
+		if first >= len(m.organismUpdateOrder) {
+			break
+		}
+		last := first + chunkSize
+		if last > len(m.organismUpdateOrder) {
+			last = len(m.organismUpdateOrder)
+		}
+		wg.Add(1)
+		go func(ids []int, worker int) {
+			defer wg.Done()
+			m.updateOrganismRange(ids, seed, worker)
+		}(m.organismUpdateOrder[first:last], w)
+	}
+	wg.Wait()
+}
+
+// recordTreeUsage credits each organism's latest health change and the
+// nodes it visited to the decision tree it used. Organisms are updated
+// concurrently, so this is done afterward in update order.
+func (m *OrganismManager) recordTreeUsage() {
+	for _, id := range m.organismUpdateOrder {
+		o := m.organisms[id]
+		if tree := o.DecisionTree(); tree != nil {
+			m.treeLibrary.RecordHealthChange(tree.ID, o.HealthChange())
+			m.treeLibrary.RecordVisits(tree.ID, o.DecisionPath())
+		}
+	}
+}
+
+// updateOrganismRange updates a list of organisms using the given worker's
+// random stream, reseeding it from the cycle seed and each organism's ID
+func (m *OrganismManager) updateOrganismRange(ids []int, seed int64, worker int) {
+	random, source := m.workerRandoms[worker], m.workerSources[worker]
+	for _, id := range ids {
+		source.Seed(utils.StreamSeed(seed, id))
+		m.updateOrganism(m.organisms[id], random)
 	}
+}
+
+func (m *OrganismManager) updateOrganism(o *organism.Organism, r *rand.Rand) {
 	o.UpdateStats()
-	o.UpdateAction()
+	o.UpdateAction(r)
 }
 
 func (m *OrganismManager) resolveOrganismAction(o *organism.Organism) {
@@ -180,12 +372,15 @@ func (m *OrganismManager) resolveOrganismAction(o *organism.Organism) {
 //
 // Checks random positions on the grid until it finds an empty one. Calls
 // NewOrganism to initialize decision tree, other random attributes.
-func (m *OrganismManager) SpawnRandomOrganism() {
+// Returns true / false depending on whether an organism was actually spawned.
+func (m *OrganismManager) SpawnRandomOrganism() bool {
 	if spawnPoint, found := m.getRandomSpawnLocation(); found {
 		index := m.totalOrganismsCreated
-		o := organism.NewRandom(index, spawnPoint, m.api)
-		m.registerNewOrganism(o, index)
+		o := organism.NewRandom(index, spawnPoint, m.api, m.globals, m.random)
+		m.registerNewOrganism(o, index, nil)
+		return true
 	}
+	return false
 }
 
 // SpawnChildOrganism creates a new organism near an existing 'parent' organism
@@ -193,22 +388,74 @@ func (m *OrganismManager) SpawnRandomOrganism() {
 // Returns true / false depending on whether a child was actually spawned.
 func (m *OrganismManager) SpawnChildOrganism(parent *organism.Organism) bool {
 	if spawnPoint, found := m.getChildSpawnLocation(parent); found {
-		index := m.totalOrganismsCreated
-		o := parent.NewChild(index, spawnPoint, m.api)
-		m.registerNewOrganism(o, index)
-		m.addToOriginalAncestors(parent)
+		m.spawnChildAt(parent, spawnPoint)
 		return true
 	}
 	return false
 }
 
-func (m *OrganismManager) registerNewOrganism(o *organism.Organism, index int) {
+// spawnChildAt creates a new child of a parent organism at a given point,
+// which must be empty
+func (m *OrganismManager) spawnChildAt(parent *organism.Organism, point utils.Point) {
+	index := m.totalOrganismsCreated
+	mate := m.findMate(parent)
+	var o *organism.Organism
+	if mate != nil {
+		o = parent.NewCrossoverChild(mate, index, point, m.api, m.random)
+		mate.Children++
+		m.treeLibrary.RecordChild(mate.DecisionTreeID())
+	} else {
+		o = parent.NewChild(index, point, m.api, m.random)
+	}
+	m.registerNewOrganism(o, index, parent)
+	m.treeLibrary.RecordChild(parent.DecisionTreeID())
+	m.addToOriginalAncestors(parent)
+}
+
+// findMate returns the first living organism ahead, left, right or behind a
+// parent that's compatible for crossover reproduction (with the same kind of
+// brain), or nil if there is none or crossover is disabled
+func (m *OrganismManager) findMate(parent *organism.Organism) *organism.Organism {
+	if m.globals.ReproductionMode != c.ReproduceCrossover {
+		return nil
+	}
+	directions := []utils.Point{
+		parent.Direction,
+		parent.Direction.Left(),
+		parent.Direction.Right(),
+		parent.Direction.Left().Left(),
+	}
+	for _, direction := range directions {
+		mate := m.getOrganismAt(parent.Location.Add(direction).Wrap(m.globals))
+		if mate == nil || mate == parent || mate.Health <= 0 || !mate.HasSameBrainType(parent) {
+			continue
+		}
+		if m.globals.CrossoverRelatedOnly && mate.OriginalAncestorID != parent.OriginalAncestorID {
+			continue
+		}
+		return mate
+	}
+	return nil
+}
+
+// registerNewOrganism adds a newly-created organism to the grid and records
+// its birth, to a parent organism if it has one
+func (m *OrganismManager) registerNewOrganism(o *organism.Organism, index int, parent *organism.Organism) {
 	m.addUpdatedPoint(o.Location)
 
 	m.organisms[index] = o
 	m.totalOrganismsCreated++
 	m.organismIDGrid[o.X()][o.Y()] = index
 	m.newOrganismIDs = append(m.newOrganismIDs, index)
+	o.ShareDecisionTree(m.treePool)
+	if tree := o.DecisionTree(); tree != nil && m.treeLibrary.RecordBirth(tree, m.api.Cycle()) {
+		m.lastNewTreeCycle = m.api.Cycle()
+	}
+	if parent == nil {
+		m.genealogy.Born(o.ID, genealogy.NoParent, m.api.Cycle(), false)
+	} else {
+		m.genealogy.Born(o.ID, parent.ID, m.api.Cycle(), !o.HasSameBrain(parent))
+	}
 }
 
 func (m *OrganismManager) addToOriginalAncestors(o *organism.Organism) {
@@ -222,19 +469,19 @@ func (m *OrganismManager) addToOriginalAncestors(o *organism.Organism) {
 
 // returns a random point and whether it is empty
 func (m *OrganismManager) getRandomSpawnLocation() (utils.Point, bool) {
-	point := utils.GetRandomPoint(c.GridUnitsWide(), c.GridUnitsHigh())
+	point := utils.GetRandomPoint(m.globals.GridUnitsWide, m.globals.GridUnitsHigh, m.random)
 	return point, m.isGridLocationEmpty(point)
 }
 
 func (m *OrganismManager) getChildSpawnLocation(parent *organism.Organism) (utils.Point, bool) {
-	direction := utils.GetRandomDirection()
-	point := parent.Location.Add(direction)
+	direction := utils.GetRandomDirection(m.random)
+	point := parent.Location.Add(direction).Wrap(m.globals)
 	for i := 0; i < 4; i++ {
 		if m.isGridLocationEmpty(point) {
 			return point, true
 		}
 		direction = direction.Left()
-		point = parent.Location.Add(direction)
+		point = parent.Location.Add(direction).Wrap(m.globals)
 	}
 	return point, false
 }
@@ -292,6 +539,15 @@ func (m *OrganismManager) GetOrganismDecisionTreeByID(id int) *d.Tree {
 	return nil
 }
 
+// GetOrganismBrainByID returns a copy of the brain of the given organism (nil
+// if no organism found)
+func (m *OrganismManager) GetOrganismBrainByID(id int) brain.Brain {
+	if o, ok := m.organisms[id]; ok {
+		return o.GetBrainCopy()
+	}
+	return nil
+}
+
 // GetOrganismInfoByID returns the Organism Info for a given Organism ID. (nil if not found)
 func (m *OrganismManager) GetOrganismInfoByID(id int) *organism.Info {
 	if o, found := m.organisms[id]; found {
@@ -319,6 +575,52 @@ func (m *OrganismManager) DeadCount() int {
 	return m.totalOrganismsCreated - len(m.organisms)
 }
 
+// CreatedCount returns the total number of organisms created in the simulation
+func (m *OrganismManager) CreatedCount() int {
+	return m.totalOrganismsCreated
+}
+
+// LineageCount returns the number of original ancestors with at least one
+// living descendant (including the ancestors themselves)
+func (m *OrganismManager) LineageCount() int {
+	lineages := make(map[int]bool)
+	for _, o := range m.organisms {
+		lineages[o.OriginalAncestorID] = true
+	}
+	return len(lineages)
+}
+
+// TreeLibrary returns every decision tree seen in the population, with its
+// stats. The library must not be modified.
+func (m *OrganismManager) TreeLibrary() d.Library {
+	return m.treeLibrary
+}
+
+// Mortality returns the number of deaths by cause, in total and by lineage
+func (m *OrganismManager) Mortality() *genealogy.Mortality {
+	return m.mortality
+}
+
+// Genealogy returns the parent, birth and death records of every organism
+func (m *OrganismManager) Genealogy() *genealogy.Genealogy {
+	return m.genealogy
+}
+
+// LastNewTreeCycle returns the last cycle a decision tree that had never been
+// seen before appeared in the population
+func (m *OrganismManager) LastNewTreeCycle() int {
+	return m.lastNewTreeCycle
+}
+
+// ForEachOrganism calls visit on every living organism, in update order
+func (m *OrganismManager) ForEachOrganism(visit func(o *organism.Organism)) {
+	for _, id := range m.organismUpdateOrder {
+		if o, ok := m.organisms[id]; ok {
+			visit(o)
+		}
+	}
+}
+
 func (m *OrganismManager) applyAction(o *organism.Organism) {
 	switch o.Action() {
 	case d.ActChemosynthesis:
@@ -345,19 +647,26 @@ func (m *OrganismManager) applyAction(o *organism.Organism) {
 	case d.ActSpawn:
 		m.applySpawn(o)
 		break
+	default:
+		o.ApplyFlagAction()
 	}
 }
 
 func (m *OrganismManager) applyCycleHealthChanges(o *organism.Organism) {
-	decisionsEffect := c.HealthChangePerDecisionTreeNode() * float64(o.GetCurrentDecisionTreeLength())
+	decisionsEffect := m.globals.HealthChangePerDecisionTreeNode * float64(o.BrainSize())
 	phEffect := 0.0
 	// Subtract health if organism is too far away from its ideal ph
 	phDist := math.Abs(o.Traits().IdealPh - m.api.GetPhAtPoint(o.Location))
 	if phDist > o.Traits().PhTolerance {
-		phEffect = (phDist - o.Traits().PhTolerance) * c.HealthChangePerUnhealthyPh()
+		phEffect = (phDist - o.Traits().PhTolerance) * m.globals.HealthChangePerCycleUnhealthyPh
 	}
 
-	m.applyHealthChange(o, (decisionsEffect+phEffect)*o.Size)
+	// whichever cost is greater is blamed if the organism dies
+	cause := genealogy.CauseStarvation
+	if phEffect < decisionsEffect {
+		cause = genealogy.CausePh
+	}
+	m.applyHealthChange(o, (decisionsEffect+phEffect)*o.Size, cause)
 }
 
 // add a positive health change if organism attempts chemosynthesis in a
@@ -367,13 +676,25 @@ func (m *OrganismManager) applyChemosynthesis(o *organism.Organism) {
 	ideal := o.Traits().IdealPh
 	tolerance := o.Traits().PhTolerance
 	if math.Abs(ideal-ph) < tolerance {
-		m.applyHealthChange(o, c.HealthChangeFromChemosynthesis()*o.Size)
+		m.applyHealthChange(o, m.globals.HealthChangeFromChemosynthesis*o.Size, genealogy.CauseStarvation)
 	}
 }
 
-func (m *OrganismManager) applyHealthChange(o *organism.Organism, amount float64) {
+// applyHealthChange changes an organism's health, blaming the given cause if
+// it dies
+func (m *OrganismManager) applyHealthChange(o *organism.Organism, amount float64, cause genealogy.Cause) {
+	m.applyHealthChangeFrom(o, amount, cause, nil)
+}
+
+// applyHealthChangeFrom changes an organism's health, blaming the given
+// cause and source organism (if any) if it dies
+func (m *OrganismManager) applyHealthChangeFrom(o *organism.Organism, amount float64, cause genealogy.Cause, source *organism.Organism) {
+	killerID := genealogy.NoKiller
+	if source != nil {
+		killerID = source.ID
+	}
 	prevSize := o.Size
-	o.ApplyHealthChange(amount)
+	o.ApplyHealthChangeFrom(amount, cause, killerID)
 	if o.Size > prevSize {
 		m.addUpdatedPoint(o.Location)
 		// Organism growth affects ph
@@ -383,12 +704,12 @@ func (m *OrganismManager) applyHealthChange(o *organism.Organism, amount float64
 
 func (m *OrganismManager) applyAttack(o *organism.Organism) {
 	m.addUpdatedPoint(o.Location)
-	m.applyHealthChange(o, c.HealthChangeFromAttacking()*o.Size)
-	targetPoint := o.Location.Add(o.Direction)
+	m.applyHealthChange(o, m.globals.HealthChangeFromAttacking*o.Size, genealogy.CauseStarvation)
+	targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
 	if m.isOrganismAtLocation(targetPoint) {
 		targetOrganismIndex := m.organismIDGrid[targetPoint.X][targetPoint.Y]
 		targetOrganism := m.organisms[targetOrganismIndex]
-		m.applyHealthChange(targetOrganism, c.HealthChangeInflictedByAttack()*o.Size)
+		m.applyHealthChangeFrom(targetOrganism, m.globals.HealthChangeInflictedByAttack*o.Size, genealogy.CauseAttack, o)
 		m.removeIfDead(targetOrganism)
 	}
 }
@@ -401,44 +722,50 @@ func (m *OrganismManager) removeIfDead(o *organism.Organism) bool {
 	m.organismIDGrid[o.Location.X][o.Location.Y] = -1
 	m.api.AddFoodAtPoint(o.Location, int(o.Size))
 	delete(m.organisms, o.ID)
+	o.ReleaseDecisionTree(m.treePool)
+	m.treeLibrary.RecordDeath(o.DecisionTreeID(), o.Age, m.api.Cycle())
+	cause, killerID := o.CauseOfDeath()
+	m.genealogy.Died(o.ID, m.api.Cycle(), cause, killerID)
+	m.mortality.Record(o.OriginalAncestorID, cause)
+	m.archiveIfNotable(o)
 	return true
 }
 
 func (m *OrganismManager) applySpawn(o *organism.Organism) {
-	m.applyHealthChange(o, o.HealthCostToReproduce())
+	m.applyHealthChange(o, o.HealthCostToReproduce(), genealogy.CauseSpawning)
 	if success := m.SpawnChildOrganism(o); success {
 		o.Children++
 	}
 }
 
 func (m *OrganismManager) applyFeed(o *organism.Organism) {
-	m.applyHealthChange(o, c.HealthChangeFromFeeding()*o.Size)
-	amountToFeed := c.HealthChangeFromFeeding() * o.Size
-	targetPoint := o.Location.Add(o.Direction)
+	m.applyHealthChange(o, m.globals.HealthChangeFromFeeding*o.Size, genealogy.CauseStarvation)
+	amountToFeed := m.globals.HealthChangeFromFeeding * o.Size
+	targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
 	if m.isOrganismAtLocation(targetPoint) {
 		targetOrganismIndex := m.organismIDGrid[targetPoint.X][targetPoint.Y]
 		targetOrganism := m.organisms[targetOrganismIndex]
-		m.applyHealthChange(targetOrganism, amountToFeed)
+		m.applyHealthChangeFrom(targetOrganism, amountToFeed, genealogy.CauseFeeding, o)
 	} else {
 		m.api.AddFoodAtPoint(targetPoint, int(amountToFeed))
 	}
 }
 
 func (m *OrganismManager) applyEat(o *organism.Organism) {
-	m.applyHealthChange(o, c.HealthChangeFromEatingAttempt()*o.Size)
-	targetPoint := o.Location.Add(o.Direction)
+	m.applyHealthChange(o, m.globals.HealthChangeFromEatingAttempt*o.Size, genealogy.CauseStarvation)
+	targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
 	if item := m.api.GetFoodAtPoint(targetPoint); item != nil {
 		maxCanEat := o.Size
 		amountToEat := math.Min(float64(item.Value), maxCanEat)
 		m.api.RemoveFoodAtPoint(targetPoint, int(amountToEat))
-		m.applyHealthChange(o, amountToEat)
+		m.applyHealthChange(o, amountToEat, genealogy.CauseStarvation)
 	}
 }
 
 func (m *OrganismManager) applyMove(o *organism.Organism) {
-	m.applyHealthChange(o, c.HealthChangeFromMoving()*o.Size)
+	m.applyHealthChange(o, m.globals.HealthChangeFromMoving*o.Size, genealogy.CauseStarvation)
 
-	targetPoint := o.Location.Add(o.Direction)
+	targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
 	if m.isGridLocationEmpty(targetPoint) {
 		m.addUpdatedPoint(o.Location)
 		m.addUpdatedPoint(targetPoint)
@@ -450,13 +777,13 @@ func (m *OrganismManager) applyMove(o *organism.Organism) {
 }
 
 func (m *OrganismManager) applyRightTurn(o *organism.Organism) {
-	m.applyHealthChange(o, c.HealthChangeFromTurning()*o.Size)
+	m.applyHealthChange(o, m.globals.HealthChangeFromTurning*o.Size, genealogy.CauseStarvation)
 
 	o.Direction = o.Direction.Right()
 }
 
 func (m *OrganismManager) applyLeftTurn(o *organism.Organism) {
-	m.applyHealthChange(o, c.HealthChangeFromTurning()*o.Size)
+	m.applyHealthChange(o, m.globals.HealthChangeFromTurning*o.Size, genealogy.CauseStarvation)
 
 	o.Direction = o.Direction.Left()
 }
@@ -477,12 +804,12 @@ func (m *OrganismManager) printOrganismInfo(o *organism.Organism) string {
 		"\nAncestor: %10d   |  "+
 		"\n  Health: %10.2f   |   ChanceToMutateTree:  %4.2f"+
 		"\n    CalcAndUpdateSize: %10.2f   |              MaxSize:  %4.2f"+
-		"\n  Tree:\n%s",
+		"\n  Brain:\n%s",
 		o.ID, int(o.InitialHealth()),
 		o.Age, int(o.MinHealthToSpawn()),
 		o.Children, o.MinCyclesBetweenSpawns(),
 		o.OriginalAncestorID,
 		o.Health, o.ChanceToMutateDecisionTree(),
 		o.Size, o.MaxSize(),
-		o.GetDecisionTreeCopy().Print())
+		o.GetBrainCopy().Describe())
 }
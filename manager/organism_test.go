@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/brain"
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/organism"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// crossoverGlobals reproduces by crossover in the small world used to
+// resolve actions, with room for children's traits to mutate
+func crossoverGlobals() *c.Globals {
+	g := resolveGlobals(c.ConflictRandom)
+	g.ReproductionMode = c.ReproduceCrossover
+	g.MaxDecisionTreeSize = 32
+	g.MinimumMaxSize, g.MaximumMaxSize = 10, 100
+	g.MinSpawnHealth, g.MaxSpawnHealthPercent = 1, 0.5
+	g.MaxCyclesBetweenSpawns = 100
+	g.MinIdealPh, g.MaxIdealPh = 1, 9
+	g.MinPhTolerance, g.MaxPhTolerance = 0.7, 0.9
+	return g
+}
+
+// testParent returns the snapshot of an organism using the given decision
+// tree, which it never mutates
+func testParent(t *testing.T, id int, point, direction utils.Point, tree string) organism.Snapshot {
+	parent := testOrganism(id, point, direction, 10, d.ActChemosynthesis)
+	decisionTree, err := d.ParseTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.DecisionTree = decisionTree
+	parent.OriginalAncestorID = id
+	return parent
+}
+
+// newManager creates the organisms without resolving any actions
+func newManager(g *c.Globals, organisms ...organism.Snapshot) *OrganismManager {
+	return NewOrganismManagerFromSnapshot(newTestAPI(), g, rand.New(rand.NewSource(1)), OrganismSnapshot{
+		Organisms:             organisms,
+		TotalOrganismsCreated: len(organisms),
+	})
+}
+
+func TestFindMate(t *testing.T) {
+	// the mate is behind the parent, which still finds it
+	parent := testParent(t, 0, utils.Point{X: 2, Y: 0}, right, "Eat")
+	mate := testParent(t, 1, utils.Point{X: 1, Y: 0}, right, "MoveAhead")
+
+	g := crossoverGlobals()
+	m := newManager(g, parent, mate)
+	assert.Same(t, m.organisms[1], m.findMate(m.organisms[0]))
+
+	// unrelated organisms only mate if allowed
+	g.CrossoverRelatedOnly = true
+	assert.Nil(t, m.findMate(m.organisms[0]))
+	m.organisms[1].OriginalAncestorID = 0
+	assert.Same(t, m.organisms[1], m.findMate(m.organisms[0]))
+
+	// dead organisms don't mate
+	m.organisms[1].Health = 0
+	assert.Nil(t, m.findMate(m.organisms[0]))
+
+	g = crossoverGlobals()
+	g.ReproductionMode = c.ReproduceAsexual
+	m = newManager(g, parent, mate)
+	assert.Nil(t, m.findMate(m.organisms[0]))
+}
+
+func TestSpawnCrossoverChild(t *testing.T) {
+	first := "(IfFoodAhead Eat TurnLeft)"
+	second := "(IfCanMoveAhead MoveAhead TurnRight)"
+	m := newManager(crossoverGlobals(),
+		testParent(t, 0, utils.Point{X: 1, Y: 0}, right, first),
+		testParent(t, 1, utils.Point{X: 2, Y: 0}, left, second),
+	)
+	parent, mate := m.organisms[0], m.organisms[1]
+	m.spawnChildAt(parent, utils.Point{X: 1, Y: 1})
+
+	// the child belongs to the parent's lineage, but its tree takes a
+	// subtree from its mate's
+	child := m.organisms[2]
+	assert.Equal(t, parent.OriginalAncestorID, child.OriginalAncestorID)
+	assert.NotEqual(t, parent.DecisionTreeID(), child.DecisionTreeID())
+	assert.Equal(t, 1, mate.Children)
+
+	record, _ := m.genealogy.Get(child.ID)
+	assert.Equal(t, int32(parent.ID), record.Parent)
+	assert.True(t, record.Mutated)
+}
+
+func TestCrossoverNeedsSameBrainType(t *testing.T) {
+	g := crossoverGlobals()
+	g.NetworkHiddenNodes = 2
+	r := rand.New(rand.NewSource(1))
+	withNetwork := func(snapshot organism.Snapshot) organism.Snapshot {
+		snapshot.DecisionTree, snapshot.Network = nil, brain.NewNetwork(g, r)
+		return snapshot
+	}
+	tree := testParent(t, 0, utils.Point{X: 1, Y: 0}, right, "Eat")
+	network := withNetwork(testParent(t, 1, utils.Point{X: 2, Y: 0}, left, "Eat"))
+	m := newManager(g, tree, network)
+	assert.Nil(t, m.findMate(m.organisms[0]))
+	assert.Nil(t, m.findMate(m.organisms[1]))
+
+	// networks mate with networks, and their child has one too
+	m = newManager(g, withNetwork(tree), network)
+	parent, mate := m.organisms[0], m.organisms[1]
+	assert.Same(t, mate, m.findMate(parent))
+	m.spawnChildAt(parent, utils.Point{X: 1, Y: 1})
+	assert.True(t, m.organisms[2].HasSameBrainType(parent))
+	assert.Equal(t, 1, mate.Children)
+}
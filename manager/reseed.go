@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"fmt"
+	"log"
+
+	c "github.com/Zebbeni/protozoa/config"
+	"github.com/Zebbeni/protozoa/organism"
+)
+
+// spawnAttemptsPerOrganism limits how many random locations are tried for
+// each organism reseeded or seeded, since the grid may be crowded with food
+const spawnAttemptsPerOrganism = 10
+
+// reseed tops the population back up to MinOrganisms using the configured
+// reseed strategy, and logs how many organisms were added
+func (m *OrganismManager) reseed() {
+	missing := m.globals.MinOrganisms - len(m.organisms)
+	if missing <= 0 || m.globals.ReseedStrategy == c.ReseedNone {
+		return
+	}
+
+	// survivors are chosen from the organisms alive before reseeding, in
+	// update order so the choice is reproducible
+	survivors := make([]*organism.Organism, 0, len(m.organisms))
+	m.ForEachOrganism(func(o *organism.Organism) {
+		survivors = append(survivors, o)
+	})
+
+	added := 0
+	for attempt := 0; added < missing && attempt < missing*spawnAttemptsPerOrganism; attempt++ {
+		var source *organism.Organism
+		switch m.globals.ReseedStrategy {
+		case c.ReseedRandom:
+			// no source, so a random organism is added below
+		case c.ReseedSurvivor:
+			if len(survivors) > 0 {
+				source = survivors[m.random.Intn(len(survivors))]
+			}
+		case c.ReseedArchive:
+			if len(m.archive) > 0 {
+				source = m.archive[m.random.Intn(len(m.archive))]
+			}
+		default:
+			// unknown strategies are rejected by Globals.Validate
+			panic(fmt.Sprintf("unknown reseed strategy %q", m.globals.ReseedStrategy))
+		}
+
+		// fall back to random organisms when there's nothing to clone
+		if source == nil {
+			if m.SpawnRandomOrganism() {
+				added++
+			}
+		} else if m.spawnCloneOrganism(source) {
+			added++
+		}
+	}
+
+	if added > 0 {
+		m.reseedCount += added
+		log.Printf("cycle %d: reseeded %d of %d missing organisms (%s)",
+			m.api.Cycle(), added, missing, m.globals.ReseedStrategy)
+	}
+}
+
+// spawnCloneOrganism creates a mutated clone of a source organism at a random
+// empty location. Returns true / false depending on whether a clone was
+// actually spawned.
+func (m *OrganismManager) spawnCloneOrganism(source *organism.Organism) bool {
+	spawnPoint, found := m.getRandomSpawnLocation()
+	if !found {
+		return false
+	}
+	index := m.totalOrganismsCreated
+	o := source.NewClone(index, spawnPoint, m.api, m.random)
+	m.registerNewOrganism(o, index, source)
+	return true
+}
+
+// archiveIfNotable adds a dead organism to the reseed archive if it had more
+// children than the least successful organism already archived. The archive
+// is kept sorted from most to fewest children.
+func (m *OrganismManager) archiveIfNotable(o *organism.Organism) {
+	size := m.globals.ReseedArchiveSize
+	if size <= 0 || o.Children == 0 {
+		return
+	}
+	if len(m.archive) >= size && o.Children <= m.archive[len(m.archive)-1].Children {
+		return
+	}
+
+	index := len(m.archive)
+	for index > 0 && m.archive[index-1].Children < o.Children {
+		index--
+	}
+	m.archive = append(m.archive, nil)
+	copy(m.archive[index+1:], m.archive[index:])
+	m.archive[index] = o
+	if len(m.archive) > size {
+		m.archive = m.archive[:size]
+	}
+}
+
+// ReseedCount returns the total number of organisms added to keep the
+// population at MinOrganisms
+func (m *OrganismManager) ReseedCount() int {
+	return m.reseedCount
+}
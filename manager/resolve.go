@@ -0,0 +1,254 @@
+package manager
+
+import (
+	"math"
+
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/genealogy"
+	"github.com/Zebbeni/protozoa/organism"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// claim is a request by one or more organisms for something only one of them
+// can have, such as an empty grid location or a food item
+type claim struct {
+	point     utils.Point
+	claimants []*organism.Organism
+}
+
+// claims groups organisms by the point they claim, keeping points in the order
+// they were first claimed so resolution never depends on map ordering
+type claims struct {
+	byPoint map[utils.Point]*claim
+	ordered []*claim
+}
+
+func newClaims() *claims {
+	return &claims{byPoint: make(map[utils.Point]*claim)}
+}
+
+func (cl *claims) add(point utils.Point, o *organism.Organism) {
+	if existing, ok := cl.byPoint[point]; ok {
+		existing.claimants = append(existing.claimants, o)
+		return
+	}
+	newClaim := &claim{point: point, claimants: []*organism.Organism{o}}
+	cl.byPoint[point] = newClaim
+	cl.ordered = append(cl.ordered, newClaim)
+}
+
+// resolveSimultaneously applies all organisms' chosen actions as if they
+// happened at the same time. Everything is decided from the world state at
+// the start of the resolve phase, so an organism's place in the update order
+// gives it no advantage. Actions are applied in stages:
+//  1. health changes from decision tree size and ph for all organisms
+//  2. uncontested actions: chemosynthesis, turns, feeding, attacks and
+//     memory flag changes. The damage or health given to other organisms by
+//     attacks and feeds is added up and applied to each once everything else
+//     in this stage is done.
+//  3. eating, with food wanted by multiple organisms settled by ConflictRule
+//  4. moves and spawns, with empty locations wanted by multiple organisms
+//     settled by ConflictRule
+//
+// Organisms killed in an earlier stage take no further actions and can't be
+// brought back to health, and all dead organisms are removed at the end.
+func (m *OrganismManager) resolveSimultaneously() {
+	acting := make([]*organism.Organism, 0, len(m.organismUpdateOrder))
+	for _, id := range m.organismUpdateOrder {
+		o := m.organisms[id]
+		m.applyCycleHealthChanges(o)
+		if o.Health > 0.0 {
+			acting = append(acting, o)
+		}
+	}
+
+	effects := newHealthEffects()
+	for _, o := range acting {
+		switch o.Action() {
+		case d.ActChemosynthesis:
+			m.applyChemosynthesis(o)
+		case d.ActTurnLeft:
+			m.applyLeftTurn(o)
+		case d.ActTurnRight:
+			m.applyRightTurn(o)
+		case d.ActFeed:
+			m.applySimultaneousFeed(o, effects)
+		case d.ActAttack:
+			m.applySimultaneousAttack(o, effects)
+		default:
+			o.ApplyFlagAction()
+		}
+	}
+	m.applyHealthEffects(effects)
+
+	foodClaims, locationClaims := newClaims(), newClaims()
+	for _, o := range acting {
+		if o.Health <= 0.0 {
+			continue
+		}
+		switch o.Action() {
+		case d.ActEat:
+			m.applyHealthChange(o, m.globals.HealthChangeFromEatingAttempt*o.Size, genealogy.CauseStarvation)
+			targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
+			if m.api.GetFoodAtPoint(targetPoint) != nil {
+				foodClaims.add(targetPoint, o)
+			}
+		case d.ActMove:
+			m.applyHealthChange(o, m.globals.HealthChangeFromMoving*o.Size, genealogy.CauseStarvation)
+			targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
+			if m.isGridLocationEmpty(targetPoint) {
+				locationClaims.add(targetPoint, o)
+			}
+		case d.ActSpawn:
+			m.applyHealthChange(o, o.HealthCostToReproduce(), genealogy.CauseSpawning)
+			if spawnPoint, found := m.getChildSpawnLocation(o); found {
+				locationClaims.add(spawnPoint, o)
+			}
+		}
+	}
+
+	for _, foodClaim := range foodClaims.ordered {
+		m.resolveFoodClaim(foodClaim)
+	}
+	for _, locationClaim := range locationClaims.ordered {
+		m.resolveLocationClaim(locationClaim)
+	}
+
+	for _, id := range m.organismUpdateOrder {
+		if o, ok := m.organisms[id]; ok {
+			m.removeIfDead(o)
+		}
+	}
+}
+
+// healthEffect is the combined health change made to one organism by the
+// attacks and feeds of others in a cycle
+type healthEffect struct {
+	target *organism.Organism
+	change float64
+	// the source of the most damage, blamed if the target dies
+	blamed      *organism.Organism
+	blamedCause genealogy.Cause
+	worstChange float64
+}
+
+// healthEffects groups health changes by the organism they're made to,
+// keeping targets in the order they were first affected
+type healthEffects struct {
+	byTarget map[int]*healthEffect
+	ordered  []*healthEffect
+}
+
+func newHealthEffects() *healthEffects {
+	return &healthEffects{byTarget: make(map[int]*healthEffect)}
+}
+
+func (he *healthEffects) add(target, source *organism.Organism, change float64, cause genealogy.Cause) {
+	effect, ok := he.byTarget[target.ID]
+	if !ok {
+		effect = &healthEffect{target: target}
+		he.byTarget[target.ID] = effect
+		he.ordered = append(he.ordered, effect)
+	}
+	effect.change += change
+	if change < effect.worstChange {
+		effect.blamed, effect.blamedCause, effect.worstChange = source, cause, change
+	}
+}
+
+// applyHealthEffects applies the combined attacks and feeds made to each
+// organism, skipping any that are already dead
+func (m *OrganismManager) applyHealthEffects(effects *healthEffects) {
+	for _, effect := range effects.ordered {
+		if effect.target.Health <= 0.0 {
+			continue
+		}
+		m.applyHealthChangeFrom(effect.target, effect.change, effect.blamedCause, effect.blamed)
+	}
+}
+
+// applySimultaneousAttack adds the damage to any organism ahead to the
+// cycle's health effects, so every attack made this cycle lands at once
+func (m *OrganismManager) applySimultaneousAttack(o *organism.Organism, effects *healthEffects) {
+	m.addUpdatedPoint(o.Location)
+	if target := m.getOrganismAt(o.Location.Add(o.Direction).Wrap(m.globals)); target != nil {
+		effects.add(target, o, m.globals.HealthChangeInflictedByAttack*o.Size, genealogy.CauseAttack)
+	}
+	m.applyHealthChange(o, m.globals.HealthChangeFromAttacking*o.Size, genealogy.CauseStarvation)
+}
+
+// applySimultaneousFeed adds the health given to any organism ahead to the
+// cycle's health effects, or drops it as food if there's no organism there
+func (m *OrganismManager) applySimultaneousFeed(o *organism.Organism, effects *healthEffects) {
+	m.applyHealthChange(o, m.globals.HealthChangeFromFeeding*o.Size, genealogy.CauseStarvation)
+	amountToFeed := m.globals.HealthChangeFromFeeding * o.Size
+	targetPoint := o.Location.Add(o.Direction).Wrap(m.globals)
+	if target := m.getOrganismAt(targetPoint); target != nil {
+		effects.add(target, o, amountToFeed, genealogy.CauseFeeding)
+	} else {
+		m.api.AddFoodAtPoint(targetPoint, int(amountToFeed))
+	}
+}
+
+// resolveFoodClaim lets the winning organism(s) eat from a food item
+func (m *OrganismManager) resolveFoodClaim(foodClaim *claim) {
+	item := m.api.GetFoodAtPoint(foodClaim.point)
+	if item == nil {
+		return
+	}
+
+	if m.globals.ConflictRule == c.ConflictSplit {
+		share := float64(item.Value) / float64(len(foodClaim.claimants))
+		for _, o := range foodClaim.claimants {
+			m.eatAtPoint(o, foodClaim.point, math.Min(share, o.Size))
+		}
+		return
+	}
+
+	winner := m.chooseWinner(foodClaim.claimants)
+	m.eatAtPoint(winner, foodClaim.point, math.Min(float64(item.Value), winner.Size))
+}
+
+func (m *OrganismManager) eatAtPoint(o *organism.Organism, point utils.Point, amount float64) {
+	eaten := m.api.RemoveFoodAtPoint(point, int(amount))
+	m.applyHealthChange(o, float64(eaten), genealogy.CauseStarvation)
+}
+
+// resolveLocationClaim moves the winning organism into an empty location, or
+// places its child there if it is spawning
+func (m *OrganismManager) resolveLocationClaim(locationClaim *claim) {
+	winner := m.chooseWinner(locationClaim.claimants)
+	if winner.Action() == d.ActSpawn {
+		m.spawnChildAt(winner, locationClaim.point)
+		winner.Children++
+		return
+	}
+
+	m.addUpdatedPoint(winner.Location)
+	m.addUpdatedPoint(locationClaim.point)
+	m.organismIDGrid[winner.Location.X][winner.Location.Y] = -1
+	m.organismIDGrid[locationClaim.point.X][locationClaim.point.Y] = winner.ID
+	winner.Location = locationClaim.point
+}
+
+// chooseWinner picks one organism from a list of claimants according to the
+// configured ConflictRule
+func (m *OrganismManager) chooseWinner(claimants []*organism.Organism) *organism.Organism {
+	if len(claimants) == 1 {
+		return claimants[0]
+	}
+	if m.globals.ConflictRule != c.ConflictBigger {
+		return claimants[m.random.Intn(len(claimants))]
+	}
+
+	biggest := make([]*organism.Organism, 0, len(claimants))
+	for _, o := range claimants {
+		if len(biggest) == 0 || o.Size > biggest[0].Size {
+			biggest = append(biggest[:0], o)
+		} else if o.Size == biggest[0].Size {
+			biggest = append(biggest, o)
+		}
+	}
+	return biggest[m.random.Intn(len(biggest))]
+}
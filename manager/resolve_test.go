@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/food"
+	"github.com/Zebbeni/protozoa/organism"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+var (
+	right = utils.Point{X: 1, Y: 0}
+	left  = utils.Point{X: -1, Y: 0}
+)
+
+// testAPI is a world with a fixed ph of 5 and food that only changes when
+// organisms eat it or leave it behind
+type testAPI struct {
+	food map[utils.Point]*food.Item
+}
+
+func newTestAPI() *testAPI {
+	return &testAPI{food: make(map[utils.Point]*food.Item)}
+}
+
+func (a *testAPI) CheckFoodAtPoint(point utils.Point, check organism.FoodCheck) bool {
+	return check(a.food[point])
+}
+
+func (a *testAPI) CheckOrganismAtPoint(utils.Point, organism.OrgCheck) bool { return false }
+func (a *testAPI) GetFoodAtPoint(point utils.Point) *food.Item              { return a.food[point] }
+func (a *testAPI) GetPhAtPoint(utils.Point) float64                         { return 5 }
+func (a *testAPI) OrganismCount() int                                       { return 0 }
+func (a *testAPI) Cycle() int                                               { return 0 }
+func (a *testAPI) AddPhChangeAtPoint(utils.Point, float64)                  {}
+
+func (a *testAPI) AddFoodAtPoint(point utils.Point, value int) int {
+	if value <= 0 {
+		return 0
+	}
+	if item, ok := a.food[point]; ok {
+		item.Value += value
+	} else {
+		a.food[point] = food.NewItem(point, value)
+	}
+	return value
+}
+
+func (a *testAPI) RemoveFoodAtPoint(point utils.Point, value int) int {
+	item, ok := a.food[point]
+	if !ok {
+		return 0
+	}
+	if value >= item.Value {
+		value = item.Value
+		delete(a.food, point)
+	} else {
+		item.Value -= value
+	}
+	return value
+}
+
+// resolveGlobals resolves actions simultaneously in a small world where only
+// the costs set by each test change health
+func resolveGlobals(rule string) *c.Globals {
+	return &c.Globals{
+		GridUnitsWide:        10,
+		GridUnitsHigh:        10,
+		ActionResolutionMode: c.ResolveSimultaneous,
+		ConflictRule:         rule,
+	}
+}
+
+// testOrganism returns the snapshot of an organism about to take an action,
+// with room to grow to twice its health
+func testOrganism(id int, point, direction utils.Point, health float64, action d.Action) organism.Snapshot {
+	return organism.Snapshot{
+		ID:        id,
+		Health:    health,
+		Size:      2 * health,
+		Location:  point,
+		Direction: direction,
+		Traits: organism.Traits{
+			MaxSize:     100,
+			SpawnHealth: 1,
+			IdealPh:     5,
+			PhTolerance: 1,
+		},
+		DecisionTree: d.TreeFromAction(action),
+		Action:       action,
+	}
+}
+
+// resolve creates the organisms, listed in update order, and resolves their
+// actions simultaneously
+func resolve(api *testAPI, g *c.Globals, organisms ...organism.Snapshot) *OrganismManager {
+	m := NewOrganismManagerFromSnapshot(api, g, rand.New(rand.NewSource(1)), OrganismSnapshot{
+		Organisms:             organisms,
+		TotalOrganismsCreated: len(organisms),
+	})
+	m.resolveSimultaneously()
+	return m
+}
+
+func TestBiggerOrganismWinsContestedLocation(t *testing.T) {
+	for _, order := range [][]int{{0, 1}, {1, 0}} {
+		organisms := []organism.Snapshot{
+			testOrganism(0, utils.Point{X: 1, Y: 0}, right, 20, d.ActMove),
+			testOrganism(1, utils.Point{X: 3, Y: 0}, left, 10, d.ActMove),
+		}
+		m := resolve(newTestAPI(), resolveGlobals(c.ConflictBigger), organisms[order[0]], organisms[order[1]])
+
+		assert.Equal(t, utils.Point{X: 2, Y: 0}, m.organisms[0].Location, order)
+		assert.Equal(t, utils.Point{X: 3, Y: 0}, m.organisms[1].Location, order)
+		assert.Equal(t, 0, m.organismIDGrid[2][0], order)
+	}
+}
+
+func TestSplitFoodIsDividedEvenly(t *testing.T) {
+	api := newTestAPI()
+	point := utils.Point{X: 2, Y: 0}
+	api.AddFoodAtPoint(point, 20)
+	m := resolve(api, resolveGlobals(c.ConflictSplit),
+		testOrganism(0, utils.Point{X: 1, Y: 0}, right, 30, d.ActEat),
+		testOrganism(1, utils.Point{X: 3, Y: 0}, left, 50, d.ActEat),
+	)
+
+	assert.Nil(t, api.GetFoodAtPoint(point))
+	assert.Equal(t, 40.0, m.organisms[0].Health)
+	assert.Equal(t, 60.0, m.organisms[1].Health)
+}
+
+func TestKillsCantBeUndone(t *testing.T) {
+	g := resolveGlobals(c.ConflictRandom)
+	g.HealthChangeFromChemosynthesis = 0.5
+	g.HealthChangeInflictedByAttack = -1
+	g.HealthChangeFromFeeding = 1
+	g.HealthChangePerCycleUnhealthyPh = -1
+
+	// an attack kills its target whether the target acts before or after
+	// its attacker, despite the health the target makes itself
+	for _, order := range [][]int{{0, 1}, {1, 0}} {
+		organisms := []organism.Snapshot{
+			testOrganism(0, utils.Point{X: 1, Y: 0}, right, 10, d.ActAttack),
+			testOrganism(1, utils.Point{X: 2, Y: 0}, left, 4, d.ActChemosynthesis),
+		}
+		m := resolve(newTestAPI(), g, organisms[order[0]], organisms[order[1]])
+		assert.Contains(t, m.organisms, 0, order)
+		assert.NotContains(t, m.organisms, 1, order)
+	}
+
+	// an organism killed by ph stress can't be fed back to health
+	dying := testOrganism(1, utils.Point{X: 2, Y: 0}, left, 1, d.ActChemosynthesis)
+	dying.Traits.IdealPh = 1
+	m := resolve(newTestAPI(), g, dying, testOrganism(0, utils.Point{X: 1, Y: 0}, right, 10, d.ActFeed))
+	assert.NotContains(t, m.organisms, 1)
+}
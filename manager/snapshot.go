@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/Zebbeni/protozoa/brain"
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/environment"
+	"github.com/Zebbeni/protozoa/food"
+	"github.com/Zebbeni/protozoa/genealogy"
+	"github.com/Zebbeni/protozoa/organism"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// EnvironmentSnapshot contains the full state of an EnvironmentManager
+type EnvironmentSnapshot struct {
+	PhMap [][][]float64
+}
+
+// FoodSnapshot contains the full state of a FoodManager
+type FoodSnapshot struct {
+	Items map[string]*food.Item
+}
+
+// OrganismSnapshot contains the full state of an OrganismManager. Organisms
+// are listed in their update order.
+type OrganismSnapshot struct {
+	Organisms             []organism.Snapshot
+	TotalOrganismsCreated int
+
+	OriginalAncestorsSorted []int
+	OriginalAncestorColors  map[int]color.Color
+	PopulationHistory       map[int]map[int]int16
+
+	TreeLibrary      d.Library
+	LastNewTreeCycle int
+
+	Archive     []organism.Snapshot
+	ReseedCount int
+
+	Genealogy map[int32]genealogy.Record
+	Mortality *genealogy.Mortality
+}
+
+// Snapshot returns the current state of the EnvironmentManager (both the
+// current and previous ph buffers)
+func (m *EnvironmentManager) Snapshot() EnvironmentSnapshot {
+	return EnvironmentSnapshot{PhMap: m.phMap}
+}
+
+// NewEnvironmentManagerFromSnapshot restores an EnvironmentManager from a
+// previously-saved snapshot
+func NewEnvironmentManagerFromSnapshot(api environment.API, g *c.Globals, r *rand.Rand, s EnvironmentSnapshot) *EnvironmentManager {
+	return &EnvironmentManager{
+		api:           api,
+		globals:       g,
+		random:        r,
+		phMap:         s.PhMap,
+		updatedPoints: make(map[string]utils.Point),
+	}
+}
+
+// Snapshot returns the current state of the FoodManager
+func (m *FoodManager) Snapshot() FoodSnapshot {
+	return FoodSnapshot{Items: m.Items}
+}
+
+// NewFoodManagerFromSnapshot restores a FoodManager from a previously-saved
+// snapshot
+func NewFoodManagerFromSnapshot(g *c.Globals, r *rand.Rand, s FoodSnapshot) *FoodManager {
+	items := s.Items
+	if items == nil {
+		items = make(map[string]*food.Item)
+	}
+	return &FoodManager{
+		initialized:   true,
+		globals:       g,
+		random:        r,
+		updatedPoints: make(map[string]utils.Point),
+		Items:         items,
+		grid:          newFoodGrid(g, items),
+	}
+}
+
+// Snapshot returns the current state of the OrganismManager. This should only
+// be called between cycles, when no newly-spawned organisms are waiting to be
+// added to the update order.
+func (m *OrganismManager) Snapshot() OrganismSnapshot {
+	organisms := make([]organism.Snapshot, 0, len(m.organisms))
+	for _, id := range m.organismUpdateOrder {
+		organisms = append(organisms, m.organisms[id].Snapshot())
+	}
+	archive := make([]organism.Snapshot, 0, len(m.archive))
+	for _, o := range m.archive {
+		archive = append(archive, o.Snapshot())
+	}
+	return OrganismSnapshot{
+		Organisms:               organisms,
+		TotalOrganismsCreated:   m.totalOrganismsCreated,
+		OriginalAncestorsSorted: m.originalAncestorsSorted,
+		OriginalAncestorColors:  m.originalAncestorColors,
+		PopulationHistory:       m.populationHistory,
+		TreeLibrary:             m.treeLibrary,
+		LastNewTreeCycle:        m.lastNewTreeCycle,
+		Archive:                 archive,
+		ReseedCount:             m.reseedCount,
+		Genealogy:               m.genealogy.Records(),
+		Mortality:               m.mortality,
+	}
+}
+
+// NewOrganismManagerFromSnapshot restores an OrganismManager and all its
+// organisms from a previously-saved snapshot
+func NewOrganismManagerFromSnapshot(api organism.API, g *c.Globals, r *rand.Rand, s OrganismSnapshot) *OrganismManager {
+	manager := &OrganismManager{
+		api:                     api,
+		globals:                 g,
+		random:                  r,
+		organismIDGrid:          initializeGrid(g),
+		organisms:               make(map[int]*organism.Organism),
+		totalOrganismsCreated:   s.TotalOrganismsCreated,
+		organismUpdateOrder:     make([]int, 0, len(s.Organisms)),
+		newOrganismIDs:          make([]int, 0, 100),
+		updatedPoints:           make(map[string]utils.Point),
+		originalAncestorsSorted: s.OriginalAncestorsSorted,
+		originalAncestorColors:  s.OriginalAncestorColors,
+		populationHistory:       s.PopulationHistory,
+		treeLibrary:             s.TreeLibrary,
+		treePool:                brain.NewPool(),
+		lastNewTreeCycle:        s.LastNewTreeCycle,
+		reseedCount:             s.ReseedCount,
+		genealogy:               genealogy.FromRecords(s.Genealogy, g.GenealogyRecordLimit),
+		mortality:               s.Mortality,
+	}
+	manager.SetWorkerCount(1)
+	if manager.originalAncestorColors == nil {
+		manager.originalAncestorColors = make(map[int]color.Color)
+	}
+	if manager.mortality == nil {
+		manager.mortality = genealogy.NewMortality()
+	}
+	if manager.populationHistory == nil {
+		manager.populationHistory = make(map[int]map[int]int16)
+	}
+	if manager.treeLibrary == nil {
+		manager.treeLibrary = d.NewLibrary()
+	}
+	// node sizes aren't saved, so recalculate them before using the trees
+	// (extinct trees keep only their stats)
+	for _, entry := range manager.treeLibrary {
+		if entry.Tree != nil {
+			entry.Tree.CalcAndUpdateSize()
+		}
+	}
+	for _, snapshot := range s.Organisms {
+		o := organism.FromSnapshot(snapshot, api, g)
+		manager.organisms[o.ID] = o
+		manager.organismIDGrid[o.X()][o.Y()] = o.ID
+		manager.organismUpdateOrder = append(manager.organismUpdateOrder, o.ID)
+		o.ShareDecisionTree(manager.treePool)
+	}
+	for _, snapshot := range s.Archive {
+		manager.archive = append(manager.archive, organism.FromSnapshot(snapshot, api, g))
+	}
+	return manager
+}
@@ -0,0 +1,76 @@
+package metrics
+
+// Field is a single named value in a Sample
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Stat is the mean and variance of a value across all living organisms
+type Stat struct {
+	Name     string
+	Mean     float64
+	Variance float64
+}
+
+// Count is a named count, eg. the number of organisms choosing an action
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Sample contains summary statistics of a simulation at a single cycle
+type Sample struct {
+	Cycle      int
+	Population int
+	Food       int
+	Dead       int
+	// Births and Deaths are counted since the previous sample
+	Births int
+	Deaths int
+
+	Traits       []Stat
+	MeanTreeSize float64
+	Actions      []Count
+	MeanPh       float64
+}
+
+// Fields returns all values in the sample as a flat list of named fields,
+// always in the same order
+func (s *Sample) Fields() []Field {
+	fields := []Field{
+		{"cycle", s.Cycle},
+		{"population", s.Population},
+		{"food", s.Food},
+		{"dead", s.Dead},
+		{"births", s.Births},
+		{"deaths", s.Deaths},
+	}
+	for _, trait := range s.Traits {
+		fields = append(fields,
+			Field{"trait_" + trait.Name + "_mean", trait.Mean},
+			Field{"trait_" + trait.Name + "_variance", trait.Variance})
+	}
+	fields = append(fields, Field{"mean_tree_size", s.MeanTreeSize})
+	for _, action := range s.Actions {
+		fields = append(fields, Field{"action_" + action.Name, action.Count})
+	}
+	return append(fields, Field{"mean_ph", s.MeanPh})
+}
+
+// NewStat calculates the mean and (population) variance of a list of values
+func NewStat(name string, values []float64) Stat {
+	stat := Stat{Name: name}
+	if len(values) == 0 {
+		return stat
+	}
+	for _, value := range values {
+		stat.Mean += value
+	}
+	stat.Mean /= float64(len(values))
+	for _, value := range values {
+		stat.Variance += (value - stat.Mean) * (value - stat.Mean)
+	}
+	stat.Variance /= float64(len(values))
+	return stat
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+// Writer writes each Sample recorded by a simulation
+type Writer interface {
+	Write(sample *Sample) error
+}
+
+// NewWriterForFile returns a Writer for the format given by a file name's
+// extension: .csv or .jsonl
+func NewWriterForFile(path string, w io.Writer) (Writer, error) {
+	switch filepath.Ext(path) {
+	case ".csv":
+		return NewCSVWriter(w), nil
+	case ".jsonl":
+		return NewJSONLWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported metrics file type: %s (use .csv or .jsonl)", path)
+}
+
+// CSVWriter writes samples as CSV rows, preceded by a header row
+type CSVWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w)}
+}
+
+// Write writes a single sample as a CSV row
+func (c *CSVWriter) Write(sample *Sample) error {
+	fields := sample.Fields()
+	if !c.headerWritten {
+		header := make([]string, len(fields))
+		for i, field := range fields {
+			header[i] = field.Name
+		}
+		if err := c.writer.Write(header); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	row := make([]string, len(fields))
+	for i, field := range fields {
+		row[i] = formatValue(field.Value)
+	}
+	if err := c.writer.Write(row); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// JSONLWriter writes samples as JSON objects, one per line
+type JSONLWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONLWriter returns a JSONLWriter writing to w
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{encoder: json.NewEncoder(w)}
+}
+
+// Write writes a single sample as a flat JSON object on its own line
+func (j *JSONLWriter) Write(sample *Sample) error {
+	fields := sample.Fields()
+	object := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		object[field.Name] = field.Value
+	}
+	return j.encoder.Encode(object)
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSample(cycle int) *Sample {
+	return &Sample{
+		Cycle:        cycle,
+		Population:   2,
+		Births:       3,
+		Traits:       []Stat{NewStat("max_size", []float64{10, 20})},
+		MeanTreeSize: 4.5,
+		Actions:      []Count{{Name: "eat", Count: 2}},
+		MeanPh:       5,
+	}
+}
+
+func TestNewStat(t *testing.T) {
+	stat := NewStat("x", []float64{2, 4, 4, 4, 5, 5, 7, 9})
+	assert.Equal(t, 5.0, stat.Mean)
+	assert.Equal(t, 4.0, stat.Variance)
+	assert.Equal(t, Stat{Name: "empty"}, NewStat("empty", nil))
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriterForFile("out.csv", &buffer)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(testSample(0)))
+	assert.NoError(t, writer.Write(testSample(100)))
+
+	expected := "cycle,population,food,dead,births,deaths,trait_max_size_mean,trait_max_size_variance,mean_tree_size,action_eat,mean_ph\n" +
+		"0,2,0,0,3,0,15,25,4.5,2,5\n" +
+		"100,2,0,0,3,0,15,25,4.5,2,5\n"
+	assert.Equal(t, expected, buffer.String())
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriterForFile("out.jsonl", &buffer)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(testSample(0)))
+
+	expected := `{"action_eat":2,"births":3,"cycle":0,"dead":0,"deaths":0,"food":0,"mean_ph":5,"mean_tree_size":4.5,"population":2,"trait_max_size_mean":15,"trait_max_size_variance":25}` + "\n"
+	assert.Equal(t, expected, buffer.String())
+
+	_, err = NewWriterForFile("out.txt", &buffer)
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+package organism
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/lucasb-eyer/go-colorful"
+
+	"github.com/Zebbeni/protozoa/brain"
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// BankVersion is the version of the genome bank format written by Bank.Write.
+// It should be incremented whenever the format changes in a way older
+// versions can't read.
+const BankVersion = 1
+
+// Ways of selecting genomes from a Bank to seed a simulation with
+const (
+	// SelectAll seeds every genome in the bank
+	SelectAll = "all"
+	// SelectTop seeds the genomes of the organisms with the most children
+	SelectTop = "top"
+	// SelectSample seeds genomes chosen at random
+	SelectSample = "sample"
+)
+
+// Bank holds the genomes of every organism alive at the end of a run, so
+// they can be used to seed another run, eg.
+//
+//	{"version": 1, "cycle": 5000,
+//	 "genomes": [{"id": 812, "ancestor_id": 3, "age": 140, "children": 6,
+//	   "traits": {"color": "#e04040", "max_size": 40, ...},
+//	   "tree": "(IfFoodAhead Eat TurnLeft)"}],
+//	 "trees": [{"id": "...", "tree": "(IfFoodAhead Eat TurnLeft)", "organisms": 1}]}
+type Bank struct {
+	Version int          `json:"version"`
+	Cycle   int          `json:"cycle"`
+	Genomes []*Genome    `json:"genomes"`
+	Trees   []*TreeCount `json:"trees"`
+}
+
+// Genome is what a Bank keeps of an organism: its traits and brain, and its
+// lineage and success. Exactly one of Tree (in the decision package's text
+// format) and Network is set.
+type Genome struct {
+	ID         int            `json:"id"`
+	AncestorID int            `json:"ancestor_id"`
+	Age        int            `json:"age"`
+	Children   int            `json:"children"`
+	Traits     GenomeTraits   `json:"traits"`
+	Tree       string         `json:"tree,omitempty"`
+	Network    *brain.Network `json:"network,omitempty"`
+	brain      brain.Brain    // the parsed Tree or checked Network
+}
+
+// GenomeTraits are an organism's Traits as written to a Bank
+type GenomeTraits struct {
+	Color                      colorful.HexColor `json:"color"`
+	MaxSize                    float64           `json:"max_size"`
+	SpawnHealth                float64           `json:"spawn_health"`
+	MinHealthToSpawn           float64           `json:"min_health_to_spawn"`
+	MinCyclesBetweenSpawns     int               `json:"min_cycles_between_spawns"`
+	ChanceToMutateDecisionTree float64           `json:"chance_to_mutate_decision_tree"`
+	IdealPh                    float64           `json:"ideal_ph"`
+	PhTolerance                float64           `json:"ph_tolerance"`
+	PhEffect                   float64           `json:"ph_effect"`
+}
+
+// TreeCount is the number of organisms in a Bank carrying a decision tree
+type TreeCount struct {
+	ID        string `json:"id"`
+	Tree      string `json:"tree"`
+	Organisms int    `json:"organisms"`
+}
+
+// NewBank returns a Bank of the given organisms' genomes at a cycle, with
+// the decision trees they carry ordered from most to fewest carriers
+func NewBank(cycle int, organisms []*Organism) *Bank {
+	bank := &Bank{Version: BankVersion, Cycle: cycle, Genomes: make([]*Genome, 0, len(organisms))}
+	counts := make(map[string]*TreeCount)
+	for _, o := range organisms {
+		genome := o.Genome()
+		bank.Genomes = append(bank.Genomes, genome)
+		if genome.Tree == "" {
+			continue
+		}
+		id := o.DecisionTreeID()
+		if _, ok := counts[id]; !ok {
+			counts[id] = &TreeCount{ID: id, Tree: genome.Tree}
+			bank.Trees = append(bank.Trees, counts[id])
+		}
+		counts[id].Organisms++
+	}
+	sort.SliceStable(bank.Trees, func(i, j int) bool {
+		return bank.Trees[i].Organisms > bank.Trees[j].Organisms
+	})
+	return bank
+}
+
+// Genome returns the organism's Genome
+func (o *Organism) Genome() *Genome {
+	traits := o.traits
+	genome := &Genome{
+		ID:         o.ID,
+		AncestorID: o.OriginalAncestorID,
+		Age:        o.Age,
+		Children:   o.Children,
+		Traits: GenomeTraits{
+			Color:                      colorful.HexColor(traits.OrganismColor),
+			MaxSize:                    traits.MaxSize,
+			SpawnHealth:                traits.SpawnHealth,
+			MinHealthToSpawn:           traits.MinHealthToSpawn,
+			MinCyclesBetweenSpawns:     traits.MinCyclesBetweenSpawns,
+			ChanceToMutateDecisionTree: traits.ChanceToMutateDecisionTree,
+			IdealPh:                    traits.IdealPh,
+			PhTolerance:                traits.PhTolerance,
+			PhEffect:                   traits.PhEffect,
+		},
+		brain: o.brain,
+	}
+	switch b := o.brain.(type) {
+	case *brain.Tree:
+		genome.Tree = b.String()
+	case *brain.Network:
+		genome.Network = b
+	}
+	return genome
+}
+
+// Write writes the Bank to w in JSON format
+func (b *Bank) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// LoadBank reads a Bank in JSON format, checking its version and that every
+// genome's brain can be used
+func LoadBank(reader io.Reader) (*Bank, error) {
+	bank := &Bank{}
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(bank); err != nil {
+		return nil, fmt.Errorf("failed to read genome bank: %w", err)
+	}
+	if bank.Version < 1 || bank.Version > BankVersion {
+		return nil, fmt.Errorf("unsupported genome bank version %d (expected 1 to %d)", bank.Version, BankVersion)
+	}
+	for i, genome := range bank.Genomes {
+		if err := genome.check(); err != nil {
+			return nil, fmt.Errorf("genome %d (id %d): %w", i, genome.ID, err)
+		}
+	}
+	return bank, nil
+}
+
+// check validates the Genome and parses its brain
+func (gen *Genome) check() error {
+	switch {
+	case gen.Tree != "" && gen.Network != nil:
+		return fmt.Errorf("has both a tree and a network")
+	case gen.Tree != "":
+		tree, err := d.ParseTree(gen.Tree)
+		if err != nil {
+			return err
+		}
+		gen.brain = brain.FromTree(tree)
+	case gen.Network != nil:
+		if err := gen.Network.Check(); err != nil {
+			return err
+		}
+		gen.brain = gen.Network
+	default:
+		return fmt.Errorf("has neither a tree nor a network")
+	}
+	return nil
+}
+
+// Select returns the genomes to seed a simulation with: all of them (also
+// for an empty mode), the count with the most children, or count chosen at
+// random without replacement. All genomes are returned if count is zero or
+// more than the Bank holds.
+func (b *Bank) Select(mode string, count int, r *rand.Rand) ([]*Genome, error) {
+	if count <= 0 || count > len(b.Genomes) {
+		count = len(b.Genomes)
+	}
+	switch mode {
+	case SelectAll, "":
+		return b.Genomes, nil
+	case SelectTop:
+		sorted := append([]*Genome{}, b.Genomes...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Children > sorted[j].Children
+		})
+		return sorted[:count], nil
+	case SelectSample:
+		selected := make([]*Genome, count)
+		for i, index := range r.Perm(len(b.Genomes))[:count] {
+			selected[i] = b.Genomes[index]
+		}
+		return selected, nil
+	}
+	return nil, fmt.Errorf("unknown genome bank selection %q (use %s, %s or %s)", mode, SelectAll, SelectTop, SelectSample)
+}
+
+// traits returns the Genome's traits as an organism's Traits
+func (gen *Genome) traits() Traits {
+	t := gen.Traits
+	return Traits{
+		OrganismColor:              colorful.Color(t.Color),
+		MaxSize:                    t.MaxSize,
+		SpawnHealth:                t.SpawnHealth,
+		MinHealthToSpawn:           t.MinHealthToSpawn,
+		MinCyclesBetweenSpawns:     t.MinCyclesBetweenSpawns,
+		ChanceToMutateDecisionTree: t.ChanceToMutateDecisionTree,
+		IdealPh:                    t.IdealPh,
+		PhTolerance:                t.PhTolerance,
+		PhEffect:                   t.PhEffect,
+	}
+}
+
+// NewFromGenome initializes a newborn organism with a Genome's traits and
+// brain at a given location, descending from the given original ancestor
+func NewFromGenome(id, ancestorID int, point utils.Point, genome *Genome, api LookupAPI, g *c.Globals, r *rand.Rand) *Organism {
+	traits := genome.traits()
+	inheritedBrain := genome.brain.Copy()
+	resetVisits(inheritedBrain)
+	return newOrganism(id, ancestorID, point, traits.SpawnHealth, traits, inheritedBrain, api, g, r)
+}
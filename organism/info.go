@@ -18,4 +18,5 @@ type Info struct {
 	Age        int
 	Children   int
 	PhEffect   float64
+	Flags      uint8
 }
@@ -4,10 +4,13 @@ import (
 	"image/color"
 	"math"
 	"math/rand"
+	"reflect"
 
+	"github.com/Zebbeni/protozoa/brain"
 	c "github.com/Zebbeni/protozoa/config"
 	d "github.com/Zebbeni/protozoa/decision"
 	"github.com/Zebbeni/protozoa/food"
+	"github.com/Zebbeni/protozoa/genealogy"
 	"github.com/Zebbeni/protozoa/utils"
 )
 
@@ -22,69 +25,116 @@ type Organism struct {
 	Location             utils.Point
 	Direction            utils.Point
 	OriginalAncestorID   int
+	Flags                uint8 // memory flags set and cleared by decision tree actions
 
 	traits Traits
 
-	decisionTree *d.Tree
+	brain        brain.Brain
 	action       d.Action
+	healthChange float64 // health change since the previous cycle, credited to the decision tree
+
+	// what left the organism with no health, and who was responsible
+	causeOfDeath genealogy.Cause
+	killerID     int
 
 	lookupAPI LookupAPI
+	globals   *c.Globals
+}
+
+// newOrganism initializes a newborn organism with the given traits and brain
+// at a given location, facing a random direction, with the given health and
+// descending from the given original ancestor
+func newOrganism(id, ancestorID int, point utils.Point, health float64, traits Traits, b brain.Brain, api LookupAPI, g *c.Globals, r *rand.Rand) *Organism {
+	return &Organism{
+		ID:                 id,
+		Health:             health,
+		PrevHealth:         health,
+		Size:               health,
+		Location:           point,
+		Direction:          utils.GetRandomDirection(r),
+		OriginalAncestorID: ancestorID,
+
+		traits: traits,
+		brain:  b,
+		action: d.ActChemosynthesis,
+
+		lookupAPI: api,
+		globals:   g,
+	}
 }
 
 // NewRandom initializes organism at with random grid location and direction
-func NewRandom(id int, point utils.Point, api LookupAPI) *Organism {
-	traits := newRandomTraits()
-	decisionTree := d.TreeFromAction(d.GetRandomAction())
-	for mutations := 0; mutations < c.InitialDecisionTreeMutations(); mutations++ {
-		decisionTree = d.MutateTree(decisionTree)
+func NewRandom(id int, point utils.Point, api LookupAPI, g *c.Globals, r *rand.Rand) *Organism {
+	traits := newRandomTraits(g, r)
+	randomBrain := brain.New(g, r)
+	return newOrganism(id, id, point, traits.SpawnHealth, traits, randomBrain, api, g, r)
+}
+
+// NewChild initializes and returns a new organism with a copy of its parent's
+// brain, which may be mutated
+func (o *Organism) NewChild(id int, point utils.Point, api LookupAPI, r *rand.Rand) *Organism {
+	traits := o.traits.copyMutated(o.globals, r)
+	inheritedBrain := o.brain.Copy()
+	if r.Float64() < o.ChanceToMutateDecisionTree() {
+		inheritedBrain = inheritedBrain.Mutate(o.globals, r)
 	}
-	organism := Organism{
-		ID:                   id,
-		Age:                  0,
-		Health:               traits.SpawnHealth,
-		PrevHealth:           traits.SpawnHealth,
-		Size:                 traits.SpawnHealth,
-		Children:             0,
-		CyclesSinceLastSpawn: 0,
-		Location:             point,
-		Direction:            utils.GetRandomDirection(),
-		OriginalAncestorID:   id,
-
-		traits:       traits,
-		decisionTree: decisionTree,
-		action:       d.ActChemosynthesis,
+	resetVisits(inheritedBrain)
+	child := newOrganism(id, o.OriginalAncestorID, point, o.InitialHealth(), traits, inheritedBrain, api, o.globals, r)
+	child.Flags = o.Flags
+	return child
+}
 
-		lookupAPI: api,
+// NewCrossoverChild initializes and returns a new organism with traits mixed
+// from this organism and its mate, and a brain combining both of theirs (see
+// crossoverBrain). The child belongs to this organism's lineage.
+func (o *Organism) NewCrossoverChild(mate *Organism, id int, point utils.Point, api LookupAPI, r *rand.Rand) *Organism {
+	traits := mixTraits(o.traits, mate.traits, r).copyMutated(o.globals, r)
+	inheritedBrain := o.crossoverBrain(mate, r)
+	if r.Float64() < o.ChanceToMutateDecisionTree() {
+		inheritedBrain = inheritedBrain.Mutate(o.globals, r)
 	}
-	return &organism
+	resetVisits(inheritedBrain)
+	child := newOrganism(id, o.OriginalAncestorID, point, o.InitialHealth(), traits, inheritedBrain, api, o.globals, r)
+	child.Flags = o.Flags
+	return child
+}
+
+// NewClone initializes and returns a new organism with mutated copies of this
+// organism's traits and brain, at full spawn health. Unlike NewChild, the
+// brain is always mutated and nothing is taken from this organism, which may
+// already be dead.
+func (o *Organism) NewClone(id int, point utils.Point, api LookupAPI, r *rand.Rand) *Organism {
+	traits := o.traits.copyMutated(o.globals, r)
+	clonedBrain := o.brain.Mutate(o.globals, r)
+	resetVisits(clonedBrain)
+	clone := newOrganism(id, o.OriginalAncestorID, point, traits.SpawnHealth, traits, clonedBrain, api, o.globals, r)
+	clone.Flags = o.Flags
+	return clone
 }
 
-// NewChild initializes and returns a new organism with a copied TreeLibrary from its parent
-func (o *Organism) NewChild(id int, point utils.Point, api LookupAPI) *Organism {
-	traits := o.traits.copyMutated()
-	inheritedTree := o.GetDecisionTreeCopy()
-	if rand.Float64() < o.ChanceToMutateDecisionTree() {
-		inheritedTree = d.MutateTree(inheritedTree)
+// crossoverBrain returns a decision tree made by replacing a random subtree
+// of this organism's tree with one of its mate's, or a network taking each
+// node's weights from either organism's. Different kinds of brain can't be
+// combined, so a copy of this organism's is returned instead.
+func (o *Organism) crossoverBrain(mate *Organism, r *rand.Rand) brain.Brain {
+	switch own := o.brain.(type) {
+	case *brain.Tree:
+		if other, ok := mate.brain.(*brain.Tree); ok {
+			return brain.FromTree(d.Crossover(own.Tree, other.Tree, o.globals, r))
+		}
+	case *brain.Network:
+		if other, ok := mate.brain.(*brain.Network); ok {
+			return own.Crossover(other, r)
+		}
 	}
-	organism := Organism{
-		ID:                   id,
-		Age:                  0,
-		Health:               o.InitialHealth(),
-		PrevHealth:           o.InitialHealth(),
-		Size:                 o.InitialHealth(),
-		Children:             0,
-		CyclesSinceLastSpawn: 0,
-		Location:             point,
-		Direction:            utils.GetRandomDirection(),
-		OriginalAncestorID:   o.OriginalAncestorID,
-
-		traits:       traits,
-		decisionTree: inheritedTree,
-		action:       d.ActChemosynthesis,
+	return o.brain.Copy()
+}
 
-		lookupAPI: api,
+// resetVisits clears the visit counts of a new organism's decision tree
+func resetVisits(b brain.Brain) {
+	if tree, ok := b.(*brain.Tree); ok {
+		tree.ResetVisits()
 	}
-	return &organism
 }
 
 func (o *Organism) Info() *Info {
@@ -99,6 +149,7 @@ func (o *Organism) Info() *Info {
 		Age:        o.Age,
 		Children:   o.Children,
 		PhEffect:   o.traits.PhEffect,
+		Flags:      o.Flags,
 	}
 }
 
@@ -115,48 +166,32 @@ func (o *Organism) UpdateStats() {
 	if o.CyclesSinceLastSpawn == 1 && o.Age > 1 {
 		healthChange -= o.Size * o.HealthCostToReproduce()
 	}
+	o.healthChange = healthChange
 
-	o.decisionTree.ResetUsedLastCycle()
 	o.PrevHealth = o.Health
 }
 
-// UpdateAction runs on each cycle, occasionally changing the current decision
-// tree before running it to determine its next action
-func (o *Organism) UpdateAction() {
+// UpdateAction runs on each cycle, using the organism's brain to determine
+// its next action
+func (o *Organism) UpdateAction(r *rand.Rand) {
 	if o.shouldSpawn() {
 		o.CyclesSinceLastSpawn = 0
 		o.action = d.ActSpawn
 		return
 	}
 
-	o.action = o.chooseAction(o.decisionTree.Node)
+	o.action = o.brain.Decide(sensors{o}, r)
 }
 
 func (o *Organism) shouldSpawn() bool {
 	cyclesRequirementMet := o.CyclesSinceLastSpawn >= o.MinCyclesBetweenSpawns()
 	healthRequirementMet := o.Health > o.MinHealthToSpawn()
-	populationRequirementMet := o.lookupAPI.OrganismCount() < c.MaxOrganisms()
+	populationRequirementMet := o.lookupAPI.OrganismCount() < o.globals.MaxOrganisms
 	return populationRequirementMet && cyclesRequirementMet && healthRequirementMet
 }
 
-// chooseAction walks through nodes of an organism's decision tree, eventually
-// returning the chosen action
-//
-// As chooseAction walks through nodes, it also sets UsedLastCycle=true, allowing
-// the organism to attribute success or failure to the previously-chosen path
-func (o *Organism) chooseAction(node *d.Node) d.Action {
-	node.UsedLastCycle = true
-	if node.IsAction() {
-		return node.NodeType.(d.Action)
-	}
-	if o.isConditionTrue(node.NodeType) {
-		return o.chooseAction(node.YesNode)
-	}
-	return o.chooseAction(node.NoNode)
-}
-
-func (o *Organism) isConditionTrue(cond interface{}) bool {
-	switch cond {
+func (o *Organism) isConditionTrue(condition d.Condition, param float64, r *rand.Rand) bool {
+	switch condition {
 	case d.CanMove:
 		return o.canMove()
 	case d.IsFoodAhead:
@@ -180,30 +215,130 @@ func (o *Organism) isConditionTrue(cond interface{}) bool {
 	case d.IsRelatedOrganismRight:
 		return o.isRelatedOrganismRight()
 	case d.IsRandomFiftyPercent:
-		return rand.Float32() < 0.5
+		return r.Float32() < 0.5
 	case d.IsHealthAboveFiftyPercent:
 		return o.Health > o.Size*0.5
 	case d.IsHealthyPhHere:
 		return o.isHealthyPhHere()
+	case d.IsHealthAbove:
+		return o.Health > o.Size*param
+	case d.IsRandomBelow:
+		return r.Float64() < param
+	case d.IsPhAbove:
+		return o.phFraction() > param
+	case d.IsFoodValueAheadAbove:
+		return o.isFoodValueAbove(o.pointAt(o.Direction), param*float64(o.globals.MaxFoodValue))
+	}
+	if flag, ok := condition.Flag(); ok {
+		return o.IsFlagSet(flag)
 	}
 	return false
 }
 
+// IsFlagSet returns true if the given memory flag is set
+func (o *Organism) IsFlagSet(flag int) bool {
+	return o.Flags&(1<<flag) != 0
+}
+
+// ApplyFlagAction sets or clears a memory flag, if that's the organism's
+// chosen action
+func (o *Organism) ApplyFlagAction() {
+	flag, set, ok := o.action.Flag()
+	switch {
+	case !ok:
+		return
+	case set:
+		o.Flags |= 1 << flag
+	default:
+		o.Flags &^= 1 << flag
+	}
+}
+
 // X returns the x component of the organism's location Point
 func (o *Organism) X() int { return o.Location.X }
 
 // Y returns the y component of the organism's location Point
 func (o *Organism) Y() int { return o.Location.Y }
 
-// GetDecisionTreeCopy returns a copy of an organism's currently-used decision tree
+// GetBrainCopy returns a copy of an organism's brain
+func (o *Organism) GetBrainCopy() brain.Brain {
+	return o.brain.Copy()
+}
+
+// GetDecisionTreeCopy returns a copy of an organism's currently-used decision
+// tree, or nil if its brain isn't a decision tree
 func (o *Organism) GetDecisionTreeCopy() *d.Tree {
-	return o.decisionTree.CopyTree()
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		return tree.CopyTree()
+	}
+	return nil
+}
+
+// DecisionPath returns the indexes of the decision tree nodes the organism
+// visited to choose its current action (see decision.Trace), or nil if
+// its brain isn't a decision tree
+func (o *Organism) DecisionPath() []int {
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		return tree.Path()
+	}
+	return nil
 }
 
-// GetCurrentDecisionTreeLength returns the number of nodes in the organism's currently-used
-// decision tree
-func (o *Organism) GetCurrentDecisionTreeLength() int {
-	return o.decisionTree.Size()
+// DecisionTreeID returns the ID of the organism's currently-used decision
+// tree, or an empty string if its brain isn't a decision tree
+func (o *Organism) DecisionTreeID() string {
+	if tree := o.DecisionTree(); tree != nil {
+		return tree.ID
+	}
+	return ""
+}
+
+// DecisionTree returns the organism's currently-used decision tree, which
+// must not be modified, or nil if its brain isn't a decision tree. Use
+// GetDecisionTreeCopy for a tree that can be modified.
+func (o *Organism) DecisionTree() *d.Tree {
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		return tree.Tree
+	}
+	return nil
+}
+
+// ShareDecisionTree switches the organism to the pool's shared copy of its
+// decision tree, if its brain is one (see brain.Pool)
+func (o *Organism) ShareDecisionTree(pool brain.Pool) {
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		pool.Share(tree)
+	}
+}
+
+// ReleaseDecisionTree tells the pool the organism no longer uses its decision
+// tree, if its brain is one
+func (o *Organism) ReleaseDecisionTree(pool brain.Pool) {
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		pool.Release(tree)
+	}
+}
+
+// HasSameBrainType returns true if both organisms have the same kind of brain
+func (o *Organism) HasSameBrainType(other *Organism) bool {
+	return reflect.TypeOf(o.brain) == reflect.TypeOf(other.brain)
+}
+
+// HasSameBrain returns true if the organism's brain is identical to the
+// other's, as when it inherited its parent's unchanged
+func (o *Organism) HasSameBrain(other *Organism) bool {
+	return o.brain.Equal(other.brain)
+}
+
+// HealthChange returns the organism's change in health over the previous
+// cycle, as calculated by UpdateStats, excluding any cost of reproducing
+func (o *Organism) HealthChange() float64 {
+	return o.healthChange
+}
+
+// BrainSize returns the number of nodes in the organism's brain
+func (o *Organism) BrainSize() int {
+	return o.brain.Size()
 }
 
 // GetAction returns the last-chosen Organism action
@@ -238,14 +373,6 @@ func (o Organism) Color() color.Color { return o.traits.OrganismColor }
 // MaxSize returns an organism's maximum size
 func (o *Organism) MaxSize() float64 { return o.traits.MaxSize }
 
-func (o *Organism) setDecisionTree(decisionTree *d.Tree) {
-	if o.decisionTree != nil {
-		o.decisionTree.SetUsedInCurrentTree(false)
-	}
-	o.decisionTree = decisionTree
-	o.decisionTree.SetUsedInCurrentTree(true)
-}
-
 // ApplyHealthChange adds a value to the organism's health, bounded by 0 and MaxSize
 // If new health is greater than the organism's Size, this is updated too.
 func (o *Organism) ApplyHealthChange(change float64) {
@@ -253,21 +380,45 @@ func (o *Organism) ApplyHealthChange(change float64) {
 	if o.Health > o.Size {
 		// When health increase causes size to increase, increase slowly, not all at once.
 		difference := o.Health - o.Size
-		o.Size = math.Min(o.Size+(difference*c.GrowthFactor()), o.traits.MaxSize)
+		o.Size = math.Min(o.Size+(difference*o.globals.GrowthFactor), o.traits.MaxSize)
 	}
 	o.Health = math.Min(math.Max(o.Health, 0.0), o.Size)
 }
 
+// ApplyHealthChangeFrom adds a value to the organism's health as
+// ApplyHealthChange does, and records its cause (and the ID of the organism
+// responsible, or genealogy.NoKiller) as the cause of death if it leaves the
+// organism with no health
+func (o *Organism) ApplyHealthChangeFrom(change float64, cause genealogy.Cause, killerID int) {
+	wasAlive := o.Health > 0
+	o.ApplyHealthChange(change)
+	if wasAlive && o.Health <= 0 {
+		o.causeOfDeath, o.killerID = cause, killerID
+	}
+}
+
+// CauseOfDeath returns what left the organism with no health (or CauseNone if
+// it still has some), and the ID of the organism responsible, or
+// genealogy.NoKiller
+func (o *Organism) CauseOfDeath() (genealogy.Cause, int) {
+	return o.causeOfDeath, o.killerID
+}
+
+// pointAt returns the grid point next to the organism in a given direction
+func (o *Organism) pointAt(direction utils.Point) utils.Point {
+	return o.Location.Add(direction).Wrap(o.globals)
+}
+
 func (o *Organism) isFoodAhead() bool {
-	return o.isFoodAtPoint(o.Location.Add(o.Direction))
+	return o.isFoodAtPoint(o.pointAt(o.Direction))
 }
 
 func (o *Organism) isFoodLeft() bool {
-	return o.isFoodAtPoint(o.Location.Add(o.Direction.Left()))
+	return o.isFoodAtPoint(o.pointAt(o.Direction.Left()))
 }
 
 func (o *Organism) isFoodRight() bool {
-	return o.isFoodAtPoint(o.Location.Add(o.Direction.Right()))
+	return o.isFoodAtPoint(o.pointAt(o.Direction.Right()))
 }
 
 func (o *Organism) isFoodAtPoint(point utils.Point) bool {
@@ -276,32 +427,45 @@ func (o *Organism) isFoodAtPoint(point utils.Point) bool {
 	})
 }
 
+// phFraction returns the ph at the organism's location as a fraction of the
+// range from MinPh to MaxPh
+func (o *Organism) phFraction() float64 {
+	g := o.globals
+	return (o.lookupAPI.GetPhAtPoint(o.Location) - g.MinPh) / (g.MaxPh - g.MinPh)
+}
+
+func (o *Organism) isFoodValueAbove(point utils.Point, value float64) bool {
+	return o.lookupAPI.CheckFoodAtPoint(point, func(f *food.Item) bool {
+		return f != nil && float64(f.Value) > value
+	})
+}
+
 func (o *Organism) isOrganismAhead() bool {
-	return o.isOrganismAtPoint(o.Location.Add(o.Direction))
+	return o.isOrganismAtPoint(o.pointAt(o.Direction))
 }
 
 func (o *Organism) isBiggerOrganismAhead() bool {
-	return o.isBiggerOrganismAtPoint(o.Location.Add(o.Direction))
+	return o.isBiggerOrganismAtPoint(o.pointAt(o.Direction))
 }
 
 func (o *Organism) isRelatedOrganismAhead() bool {
-	return o.isRelatedOrganismAtPoint(o.Location.Add(o.Direction))
+	return o.isRelatedOrganismAtPoint(o.pointAt(o.Direction))
 }
 
 func (o *Organism) isOrganismLeft() bool {
-	return o.isOrganismAtPoint(o.Location.Add(o.Direction.Left()))
+	return o.isOrganismAtPoint(o.pointAt(o.Direction.Left()))
 }
 
 func (o *Organism) isRelatedOrganismLeft() bool {
-	return o.isRelatedOrganismAtPoint(o.Location.Add(o.Direction.Left()))
+	return o.isRelatedOrganismAtPoint(o.pointAt(o.Direction.Left()))
 }
 
 func (o *Organism) isOrganismRight() bool {
-	return o.isOrganismAtPoint(o.Location.Add(o.Direction.Right()))
+	return o.isOrganismAtPoint(o.pointAt(o.Direction.Right()))
 }
 
 func (o *Organism) isRelatedOrganismRight() bool {
-	return o.isRelatedOrganismAtPoint(o.Location.Add(o.Direction.Right()))
+	return o.isRelatedOrganismAtPoint(o.pointAt(o.Direction.Right()))
 }
 
 func (o *Organism) isHealthyPhHere() bool {
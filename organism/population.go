@@ -0,0 +1,192 @@
+package organism
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+
+	"github.com/lucasb-eyer/go-colorful"
+
+	"github.com/Zebbeni/protozoa/brain"
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// Population describes groups of organisms to start a simulation with, each
+// with a hand-written decision tree and chosen traits, eg.
+//
+//	{"groups": [{
+//	  "name": "forager",
+//	  "count": 50,
+//	  "tree": "(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))",
+//	  "traits": {"max_size": 40, "ideal_ph": {"min": 4, "max": 6}},
+//	  "region": {"x": 0, "y": 0, "width": 20, "height": 50}
+//	}]}
+type Population struct {
+	Groups []*Group `json:"groups"`
+}
+
+// Group is a number of organisms started with the same decision tree (in the
+// decision package's text format, or random if empty) and traits. Each trait
+// not given is random, as for random organisms. Organisms are placed at
+// random within the Region, or anywhere if it's not given. Every organism in
+// a Group shares the first one as its original ancestor.
+type Group struct {
+	Name   string      `json:"name"`
+	Count  int         `json:"count"`
+	Tree   string      `json:"tree"`
+	Traits TraitSpec   `json:"traits"`
+	Region *Region     `json:"region"`
+	tree   *brain.Tree // the parsed Tree, if given
+}
+
+// TraitSpec lists the traits given for a Group, each as a single value or a
+// range to choose from at random
+type TraitSpec struct {
+	Color                      string `json:"color"` // hex, eg. "#e04040"
+	MaxSize                    *Value `json:"max_size"`
+	SpawnHealth                *Value `json:"spawn_health"`
+	MinHealthToSpawn           *Value `json:"min_health_to_spawn"`
+	MinCyclesBetweenSpawns     *Value `json:"min_cycles_between_spawns"`
+	ChanceToMutateDecisionTree *Value `json:"chance_to_mutate_decision_tree"`
+	IdealPh                    *Value `json:"ideal_ph"`
+	PhTolerance                *Value `json:"ph_tolerance"`
+	PhEffect                   *Value `json:"ph_effect"`
+}
+
+// Value is a number, or a range of numbers from Min to Max written as
+// {"min": 1, "max": 2}
+type Value struct {
+	Min, Max float64
+}
+
+// UnmarshalJSON reads a Value from either a single number or a range
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		v.Min, v.Max = number, number
+		return nil
+	}
+	var valueRange struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&valueRange); err != nil || valueRange.Min == nil || valueRange.Max == nil {
+		return fmt.Errorf("expected a number or {\"min\": ..., \"max\": ...}, got %s", data)
+	}
+	if *valueRange.Max < *valueRange.Min {
+		return fmt.Errorf("range max %g is less than min %g", *valueRange.Max, *valueRange.Min)
+	}
+	v.Min, v.Max = *valueRange.Min, *valueRange.Max
+	return nil
+}
+
+// choose returns the Value's number, or a random number within its range
+func (v Value) choose(r *rand.Rand) float64 {
+	if v.Min == v.Max {
+		return v.Min
+	}
+	return v.Min + r.Float64()*(v.Max-v.Min)
+}
+
+// Region is a rectangle of grid locations
+type Region struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// RandomPoint returns a random location within the Region
+func (reg Region) RandomPoint(r *rand.Rand) utils.Point {
+	point := utils.GetRandomPoint(reg.Width, reg.Height, r)
+	return utils.Point{X: reg.X + point.X, Y: reg.Y + point.Y}
+}
+
+// LoadPopulation reads a Population in JSON format, checking that every
+// group's decision tree can be parsed and its region fits in the grid
+func LoadPopulation(reader io.Reader, g *c.Globals) (*Population, error) {
+	population := &Population{}
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(population); err != nil {
+		return nil, fmt.Errorf("failed to read population: %w", err)
+	}
+	for i, group := range population.Groups {
+		if err := group.check(g); err != nil {
+			return nil, fmt.Errorf("population group %d (%s): %w", i, group.Name, err)
+		}
+	}
+	return population, nil
+}
+
+// check validates the Group and parses its decision tree
+func (grp *Group) check(g *c.Globals) error {
+	if grp.Count < 0 {
+		return fmt.Errorf("count %d is negative", grp.Count)
+	}
+	if grp.Tree != "" {
+		tree, err := d.ParseTree(grp.Tree)
+		if err != nil {
+			return err
+		}
+		grp.tree = brain.FromTree(tree)
+	}
+	if grp.Traits.Color != "" {
+		if _, err := colorful.Hex(grp.Traits.Color); err != nil {
+			return fmt.Errorf("invalid color %q", grp.Traits.Color)
+		}
+	}
+	if grp.Region == nil {
+		grp.Region = &Region{Width: g.GridUnitsWide, Height: g.GridUnitsHigh}
+	}
+	reg := grp.Region
+	if reg.Width < 1 || reg.Height < 1 || reg.X < 0 || reg.Y < 0 ||
+		reg.X+reg.Width > g.GridUnitsWide || reg.Y+reg.Height > g.GridUnitsHigh {
+		return fmt.Errorf("region %+v doesn't fit in the %dx%d grid", *reg, g.GridUnitsWide, g.GridUnitsHigh)
+	}
+	return nil
+}
+
+// traits returns random traits overridden by those given for the Group
+func (grp *Group) traits(g *c.Globals, r *rand.Rand) Traits {
+	traits := newRandomTraits(g, r)
+	spec := grp.Traits
+	if spec.Color != "" {
+		traits.OrganismColor, _ = colorful.Hex(spec.Color)
+	}
+	set := func(field *float64, value *Value) {
+		if value != nil {
+			*field = value.choose(r)
+		}
+	}
+	set(&traits.MaxSize, spec.MaxSize)
+	set(&traits.SpawnHealth, spec.SpawnHealth)
+	set(&traits.MinHealthToSpawn, spec.MinHealthToSpawn)
+	set(&traits.ChanceToMutateDecisionTree, spec.ChanceToMutateDecisionTree)
+	set(&traits.IdealPh, spec.IdealPh)
+	set(&traits.PhTolerance, spec.PhTolerance)
+	set(&traits.PhEffect, spec.PhEffect)
+	if spec.MinCyclesBetweenSpawns != nil {
+		traits.MinCyclesBetweenSpawns = int(spec.MinCyclesBetweenSpawns.choose(r))
+	}
+	return traits
+}
+
+// NewSeeded initializes an organism from a population Group at a given
+// location, descending from the given original ancestor
+func NewSeeded(id, ancestorID int, point utils.Point, group *Group, api LookupAPI, g *c.Globals, r *rand.Rand) *Organism {
+	traits := group.traits(g, r)
+	var seededBrain brain.Brain
+	if group.tree != nil {
+		seededBrain = group.tree.Copy()
+	} else {
+		seededBrain = brain.New(g, r)
+	}
+	return newOrganism(id, ancestorID, point, traits.SpawnHealth, traits, seededBrain, api, g, r)
+}
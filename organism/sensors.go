@@ -0,0 +1,40 @@
+package organism
+
+import (
+	"math/rand"
+
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/food"
+)
+
+// sensors gives an organism's brain what it senses about itself and its
+// surroundings (see brain.Sensors)
+type sensors struct {
+	o *Organism
+}
+
+func (s sensors) IsConditionTrue(condition d.Condition, param float64, r *rand.Rand) bool {
+	return s.o.isConditionTrue(condition, param, r)
+}
+
+func (s sensors) Health() float64 {
+	if s.o.Size <= 0 {
+		return 0
+	}
+	return s.o.Health / s.o.Size
+}
+
+func (s sensors) Ph() float64 {
+	return s.o.phFraction()
+}
+
+func (s sensors) FoodAhead() float64 {
+	value := 0.0
+	s.o.lookupAPI.CheckFoodAtPoint(s.o.pointAt(s.o.Direction), func(f *food.Item) bool {
+		if f != nil {
+			value = float64(f.Value) / float64(s.o.globals.MaxFoodValue)
+		}
+		return f != nil
+	})
+	return value
+}
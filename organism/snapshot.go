@@ -0,0 +1,89 @@
+package organism
+
+import (
+	"github.com/Zebbeni/protozoa/brain"
+	c "github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// Snapshot contains the full state of an Organism, allowing it to be saved
+// and restored exactly as it was
+type Snapshot struct {
+	ID                   int
+	Age                  int
+	Health               float64
+	PrevHealth           float64
+	Size                 float64
+	Children             int
+	CyclesSinceLastSpawn int
+	Location             utils.Point
+	Direction            utils.Point
+	OriginalAncestorID   int
+	Flags                uint8
+
+	Traits       Traits
+	DecisionTree *d.Tree        // set if the organism's brain is a decision tree
+	Trace        *d.Trace       // the decision tree's visit counts and last path
+	Network      *brain.Network // set if the organism's brain is a neural network
+	Action       d.Action
+}
+
+// Snapshot returns the current state of the organism
+func (o *Organism) Snapshot() Snapshot {
+	network, _ := o.brain.(*brain.Network)
+	var trace *d.Trace
+	if tree, ok := o.brain.(*brain.Tree); ok {
+		trace = tree.Trace()
+	}
+	return Snapshot{
+		ID:                   o.ID,
+		Age:                  o.Age,
+		Health:               o.Health,
+		PrevHealth:           o.PrevHealth,
+		Size:                 o.Size,
+		Children:             o.Children,
+		CyclesSinceLastSpawn: o.CyclesSinceLastSpawn,
+		Location:             o.Location,
+		Direction:            o.Direction,
+		OriginalAncestorID:   o.OriginalAncestorID,
+		Flags:                o.Flags,
+
+		Traits:       o.traits,
+		DecisionTree: o.GetDecisionTreeCopy(),
+		Trace:        trace,
+		Network:      network,
+		Action:       o.action,
+	}
+}
+
+// FromSnapshot restores an organism from a previously-saved Snapshot
+func FromSnapshot(s Snapshot, api LookupAPI, g *c.Globals) *Organism {
+	var savedBrain brain.Brain = s.Network
+	if s.DecisionTree != nil {
+		// node sizes aren't saved, so recalculate them before using the tree
+		s.DecisionTree.CalcAndUpdateSize()
+		savedBrain = brain.FromTrace(s.DecisionTree, s.Trace)
+	}
+	organism := Organism{
+		ID:                   s.ID,
+		Age:                  s.Age,
+		Health:               s.Health,
+		PrevHealth:           s.PrevHealth,
+		Size:                 s.Size,
+		Children:             s.Children,
+		CyclesSinceLastSpawn: s.CyclesSinceLastSpawn,
+		Location:             s.Location,
+		Direction:            s.Direction,
+		OriginalAncestorID:   s.OriginalAncestorID,
+		Flags:                s.Flags,
+
+		traits: s.Traits,
+		brain:  savedBrain,
+		action: s.Action,
+
+		lookupAPI: api,
+		globals:   g,
+	}
+	return &organism
+}
@@ -45,16 +45,16 @@ type Traits struct {
 	PhEffect float64
 }
 
-func newRandomTraits() Traits {
-	organismColor := getRandomColor()
-	maxSize := rand.Float64() * c.MaximumMaxSize()
-	spawnHealth := rand.Float64() * maxSize * c.MaxSpawnHealthPercent()
-	minHealthToSpawn := spawnHealth + rand.Float64()*(maxSize-spawnHealth)
-	minCyclesBetweenSpawns := rand.Intn(c.MaxCyclesBetweenSpawns())
-	chanceToMutateDecisionTree := math.Max(c.MinChanceToMutateDecisionTree(), rand.Float64()*c.MaxChanceToMutateDecisionTree())
-	idealPh := rand.Float64()*(c.MaxIdealPh()-c.MinIdealPh()) + c.MinIdealPh()
-	phTolerance := rand.Float64() * c.MaxPhTolerance()
-	phEffect := rand.Float64()*(c.MaxOrganismPhEffect()*2.0) - c.MaxOrganismPhEffect()
+func newRandomTraits(g *c.Globals, r *rand.Rand) Traits {
+	organismColor := getRandomColor(r)
+	maxSize := r.Float64() * g.MaximumMaxSize
+	spawnHealth := r.Float64() * maxSize * g.MaxSpawnHealthPercent
+	minHealthToSpawn := spawnHealth + r.Float64()*(maxSize-spawnHealth)
+	minCyclesBetweenSpawns := r.Intn(g.MaxCyclesBetweenSpawns)
+	chanceToMutateDecisionTree := math.Max(g.MinChanceToMutateDecisionTree, r.Float64()*g.MaxChanceToMutateDecisionTree)
+	idealPh := r.Float64()*(g.MaxIdealPh-g.MinIdealPh) + g.MinIdealPh
+	phTolerance := r.Float64() * g.MaxPhTolerance
+	phEffect := r.Float64()*(g.MaxOrganismPhEffect*2.0) - g.MaxOrganismPhEffect
 	return Traits{
 		OrganismColor:              organismColor,
 		MaxSize:                    maxSize,
@@ -68,24 +68,24 @@ func newRandomTraits() Traits {
 	}
 }
 
-func (t Traits) copyMutated() Traits {
-	organismColor := mutateColor(t.OrganismColor)
+func (t Traits) copyMutated(g *c.Globals, r *rand.Rand) Traits {
+	organismColor := mutateColor(t.OrganismColor, r)
 	// maxSize = previous +- previous +- <5.0, bounded by MinimumMaxSize and MaximumMaxSize
-	maxSize := mutateFloat(t.MaxSize, 5.0, c.MinimumMaxSize(), c.MaximumMaxSize())
+	maxSize := mutateFloat(t.MaxSize, 5.0, g.MinimumMaxSize, g.MaximumMaxSize, r)
 	// minCyclesBetweenSpawns = previous +- <=5, bounded by 0 and MaxCyclesBetweenSpawns
-	minCyclesBetweenSpawns := mutateInt(t.MinCyclesBetweenSpawns, 5, 0, c.MaxCyclesBetweenSpawns())
+	minCyclesBetweenSpawns := mutateInt(t.MinCyclesBetweenSpawns, 5, 0, g.MaxCyclesBetweenSpawns, r)
 	// spawnHealth = previous +- <0.5, bounded by MinSpawnHealth and maxSize
-	spawnHealth := mutateFloat(t.SpawnHealth, 0.5, c.MinSpawnHealth(), maxSize*c.MaxSpawnHealthPercent())
+	spawnHealth := mutateFloat(t.SpawnHealth, 0.5, g.MinSpawnHealth, maxSize*g.MaxSpawnHealthPercent, r)
 	// minHealthToSpawn = previous +- <5.0, bounded by spawnHealthPercent and maxSize (both calculated above)
-	minHealthToSpawn := mutateFloat(t.MinHealthToSpawn, 5.0, spawnHealth, maxSize)
+	minHealthToSpawn := mutateFloat(t.MinHealthToSpawn, 5.0, spawnHealth, maxSize, r)
 	// chanceToMutateDecisionTree = previous +- <0.05, bounded by MinChanceToMutateDecisionTree and MaxChanceToMutateDecisionTree
-	chanceToMutateDecisionTree := mutateFloat(t.ChanceToMutateDecisionTree, 0.05, c.MinChanceToMutateDecisionTree(), c.MaxChanceToMutateDecisionTree())
+	chanceToMutateDecisionTree := mutateFloat(t.ChanceToMutateDecisionTree, 0.05, g.MinChanceToMutateDecisionTree, g.MaxChanceToMutateDecisionTree, r)
 	// phEffect = previous +- 0.001, bounded by MaxOrganismPhEffect (and -1 * MaxOrganismPhEffect)
-	phEffect := mutateFloat(t.PhEffect, .001, c.MaxOrganismPhEffect()*-1, c.MaxOrganismPhEffect())
+	phEffect := mutateFloat(t.PhEffect, .001, g.MaxOrganismPhEffect*-1, g.MaxOrganismPhEffect, r)
 	// ideaLPh = previous += 0.1, bounded by MinIdealPh and MaxIdealPh
-	idealPh := mutateFloat(t.IdealPh, 0.1, c.MinIdealPh(), c.MaxIdealPh())
+	idealPh := mutateFloat(t.IdealPh, 0.1, g.MinIdealPh, g.MaxIdealPh, r)
 	// phTolerance = previous +- 0.1, bounded by MinPhTolerance and MaxPhTolerance
-	phTolerance := mutateFloat(t.PhTolerance, 0.1, c.MinPhTolerance(), c.MaxPhTolerance())
+	phTolerance := mutateFloat(t.PhTolerance, 0.1, g.MinPhTolerance, g.MaxPhTolerance, r)
 	return Traits{
 		OrganismColor:              organismColor,
 		MaxSize:                    maxSize,
@@ -99,42 +99,63 @@ func (t Traits) copyMutated() Traits {
 	}
 }
 
-func mutateFloat(value, maxChange, min, max float64) float64 {
-	mutated := value + maxChange - rand.Float64()*maxChange*2.0
+// mixTraits returns traits with each field taken at random from either a or b
+func mixTraits(a, b Traits, r *rand.Rand) Traits {
+	pick := func() Traits {
+		if r.Intn(2) == 0 {
+			return a
+		}
+		return b
+	}
+	return Traits{
+		OrganismColor:              pick().OrganismColor,
+		MaxSize:                    pick().MaxSize,
+		SpawnHealth:                pick().SpawnHealth,
+		MinHealthToSpawn:           pick().MinHealthToSpawn,
+		MinCyclesBetweenSpawns:     pick().MinCyclesBetweenSpawns,
+		ChanceToMutateDecisionTree: pick().ChanceToMutateDecisionTree,
+		IdealPh:                    pick().IdealPh,
+		PhTolerance:                pick().PhTolerance,
+		PhEffect:                   pick().PhEffect,
+	}
+}
+
+func mutateFloat(value, maxChange, min, max float64, r *rand.Rand) float64 {
+	mutated := value + maxChange - r.Float64()*maxChange*2.0
 	return math.Min(math.Max(mutated, min), max)
 }
 
-func mutateInt(value, maxChange, min, max int) int {
-	mutated := math.Round(float64(value) + rand.Float64()*float64(maxChange)*2.0 - (float64(maxChange)))
+func mutateInt(value, maxChange, min, max int, r *rand.Rand) int {
+	mutated := math.Round(float64(value) + r.Float64()*float64(maxChange)*2.0 - (float64(maxChange)))
 	return int(math.Min(math.Max(mutated, float64(min)), float64(max)))
 }
 
 // MutateColor returns a slight variation on a given color
-func mutateColor(originalColor colorful.Color) colorful.Color {
+func mutateColor(originalColor colorful.Color, r *rand.Rand) colorful.Color {
 	h, s, l := originalColor.HSLuv()
-	h = mutateHue(h)
-	s = mutateSaturation(s)
-	l = mutateLuminance(l)
+	h = mutateHue(h, r)
+	s = mutateSaturation(s, r)
+	l = mutateLuminance(l, r)
 	return colorful.HSLuv(h, s, l)
 }
 
-func mutateHue(h float64) float64 {
-	return math.Mod(h+360.0+(rand.Float64()*maxHueMutation*2.0)-maxHueMutation, 360)
+func mutateHue(h float64, r *rand.Rand) float64 {
+	return math.Mod(h+360.0+(r.Float64()*maxHueMutation*2.0)-maxHueMutation, 360)
 }
 
-func mutateSaturation(s float64) float64 {
-	s += rand.Float64()*maxSaturationMutation*2.0 - maxSaturationMutation
+func mutateSaturation(s float64, r *rand.Rand) float64 {
+	s += r.Float64()*maxSaturationMutation*2.0 - maxSaturationMutation
 	return math.Min(math.Max(s, minSaturation), maxSaturation)
 }
 
-func mutateLuminance(l float64) float64 {
-	l += rand.Float64()*maxLuminanceMutation*2.0 - maxLuminanceMutation
+func mutateLuminance(l float64, r *rand.Rand) float64 {
+	l += r.Float64()*maxLuminanceMutation*2.0 - maxLuminanceMutation
 	return math.Min(math.Max(l, minLuminance), maxLuminance)
 }
 
-func getRandomColor() colorful.Color {
-	h := rand.Float64() * 360.0
-	s := minSaturation + (rand.Float64() * (maxSaturation - minSaturation))
-	l := minLuminance + (rand.Float64() * (maxLuminance - minLuminance))
+func getRandomColor(r *rand.Rand) colorful.Color {
+	h := r.Float64() * 360.0
+	s := minSaturation + (r.Float64() * (maxSaturation - minSaturation))
+	l := minLuminance + (r.Float64() * (maxLuminance - minLuminance))
 	return colorful.HSLuv(h, s, l)
 }
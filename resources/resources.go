@@ -45,9 +45,9 @@ var (
 )
 
 // Init loads all fonts and images to be used in the UI
-func Init() {
+func Init(g *config.Globals) {
 	initFonts()
-	initImages()
+	initImages(g)
 }
 
 func initFonts() {
@@ -59,13 +59,13 @@ func initFonts() {
 	FontSourceCodePro8 = fontFace(sourceCode, 8)
 }
 
-func initImages() {
+func initImages(g *config.Globals) {
 	// Panel Images
 	PlayButton = loadImage("resources/images/play_button.png")
 	PauseButton = loadImage("resources/images/pause_button.png")
 
 	var dir string
-	switch config.GridUnitSize() {
+	switch g.GridUnitSize {
 	case 4:
 		dir = "4x4"
 		break
@@ -76,7 +76,7 @@ func initImages() {
 		dir = "8x8"
 		break
 	default:
-		panic(fmt.Sprintf("Unsupported grid unit size: %d", config.GridUnitSize()))
+		panic(fmt.Sprintf("Unsupported grid unit size: %d", g.GridUnitSize))
 	}
 	SquareSmall = loadImage(fmt.Sprintf("resources/images/grid/%s/square_small.png", dir))
 	SquareMedium = loadImage(fmt.Sprintf("resources/images/grid/%s/square_large.png", dir))
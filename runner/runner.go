@@ -3,12 +3,18 @@ package runner
 import (
 	"fmt"
 	"log"
-	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	c "github.com/Zebbeni/protozoa/config"
+	"github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/genealogy"
+	"github.com/Zebbeni/protozoa/metrics"
 	"github.com/Zebbeni/protozoa/resources"
 	"github.com/Zebbeni/protozoa/simulation"
+	"github.com/Zebbeni/protozoa/sweep"
 	"github.com/Zebbeni/protozoa/ux"
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -36,17 +42,21 @@ func (r *Runner) Draw(screen *ebiten.Image) {
 }
 
 func (r *Runner) Layout(_, _ int) (int, int) {
-	return c.ScreenWidth(), c.ScreenHeight()
+	globals := r.sim.Globals()
+	return globals.ScreenWidth, globals.ScreenHeight
 }
 
-func RunSimulation(opts *c.Options) {
-	resources.Init()
-	rand.Seed(int64(opts.Seed))
-
+// RunSimulation runs a new simulation with the given globals, or resumes the
+// one saved in the load file (with its saved globals) if given
+func RunSimulation(opts *c.Options, globals *c.Globals) {
 	if opts.IsHeadless {
 		sumAllCycles := 0
 		for count := 0; count < opts.TrialCount; count++ {
-			sim := simulation.NewSimulation(opts)
+			// give each trial its own seed so trials differ but remain reproducible
+			trialOpts := *opts
+			trialOpts.Seed += count
+			sim := newSimulation(&trialOpts, globals)
+			closeMetrics := startMetrics(sim, trialFile(opts, opts.MetricsFile, count))
 			start := time.Now()
 			for !sim.IsDone() {
 				sim.Update()
@@ -56,12 +66,21 @@ func RunSimulation(opts *c.Options) {
 			}
 			sumAllCycles += sim.Cycle()
 			elapsed := time.Since(start)
+			fmt.Printf("\nSimulation %d ended (%s) with %d organisms alive.", count, sim.EndReason(), sim.OrganismCount())
 			fmt.Printf("\nTotal runtime for simulation %d: %s, cycles: %d\n", count, elapsed, sim.Cycle())
+			printMortality(sim)
+			closeMetrics()
+			writeTopTrees(sim, trialFile(opts, opts.TreesFile, count), opts.TopTrees)
+			writeBank(sim, trialFile(opts, opts.BankOutput, count))
+			writeNewick(sim, trialFile(opts, opts.NewickFile, count))
+			saveSimulation(sim, trialFile(opts, opts.SaveFile, count))
 		}
 		avgCycles := sumAllCycles / opts.TrialCount
-		fmt.Printf("\nAverage number of cycles to reach 5000: %d\n", avgCycles)
+		fmt.Printf("\nAverage number of cycles per trial: %d\n", avgCycles)
 	} else {
-		sim := simulation.NewSimulation(opts)
+		sim := newSimulation(opts, globals)
+		closeMetrics := startMetrics(sim, opts.MetricsFile)
+		resources.Init(sim.Globals())
 
 		ui := ux.NewInterface(sim)
 		gameRunner := &Runner{
@@ -75,5 +94,186 @@ func RunSimulation(opts *c.Options) {
 		if err := ebiten.RunGame(gameRunner); err != nil {
 			log.Fatal(err)
 		}
+		closeMetrics()
+		writeBank(sim, opts.BankOutput)
+		writeNewick(sim, opts.NewickFile)
+		saveSimulation(sim, opts.SaveFile)
+	}
+}
+
+// RunSweep runs every simulation described by the sweep spec file, using the
+// given globals as a base, and writes the results to the sweep output file
+func RunSweep(opts *c.Options, globals *c.Globals) {
+	specFile, err := os.Open(opts.SweepFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer specFile.Close()
+
+	spec, err := sweep.LoadSpec(specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	output, err := os.Create(opts.SweepOutput)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+
+	start := time.Now()
+	progress := func(finished, total int, endReason string, cycles int) {
+		fmt.Printf("finished run %d of %d: %s after %d cycles\n", finished, total, endReason, cycles)
+	}
+	if err := sweep.Run(spec, globals, opts.Workers, output, progress); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nSweep finished in %s, results written to %s\n", time.Since(start), opts.SweepOutput)
+}
+
+// newSimulation resumes the simulation saved in the load file, if given, or
+// creates a new one otherwise
+func newSimulation(opts *c.Options, globals *c.Globals) *simulation.Simulation {
+	if opts.LoadFile == "" {
+		return simulation.NewSimulation(opts, globals)
+	}
+
+	file, err := os.Open(opts.LoadFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	sim, err := simulation.Load(file, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sim
+}
+
+// trialFile returns the output file to use for a headless trial. When running
+// multiple trials, each gets its own file, eg. out_0.csv, out_1.csv
+func trialFile(opts *c.Options, path string, trial int) string {
+	if path == "" || opts.TrialCount <= 1 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(path, ext), trial, ext)
+}
+
+// startMetrics begins recording the simulation's metrics to the given file,
+// if any, and returns a function to close it once the run ends
+func startMetrics(sim *simulation.Simulation, path string) func() {
+	if path == "" {
+		return func() {}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writer, err := metrics.NewWriterForFile(path, file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sim.SetMetricsWriter(writer)
+
+	return func() {
+		if err := sim.MetricsError(); err != nil {
+			log.Printf("failed to write metrics: %s", err)
+		}
+		if err := file.Close(); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// mortalityLineages is the number of lineages with the most deaths printed
+// by printMortality
+const mortalityLineages = 5
+
+// printMortality prints the simulation's deaths by cause, in total and for
+// the lineages with the most deaths
+func printMortality(sim *simulation.Simulation) {
+	mortality := sim.GetMortality()
+	fmt.Printf("Deaths by cause: %s\n", genealogy.Describe(mortality.Total))
+	for _, ancestorID := range mortality.DeadliestLineages(mortalityLineages) {
+		lineage := mortality.Lineage(ancestorID)
+		fmt.Printf("  lineage %d: %d deaths, %s\n", ancestorID, genealogy.Sum(lineage), genealogy.Describe(lineage))
+	}
+}
+
+// writeTopTrees writes the count decision trees with the most living carriers
+// to the given file, if any, as DOT or JSON depending on its extension
+func writeTopTrees(sim *simulation.Simulation, path string, count int) {
+	if path == "" {
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	trees := decision.Exports(sim.GetTopTrees(count))
+	if err := decision.WriteTreesForFile(path, file, trees); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nWrote %d decision trees to %s\n", len(trees), path)
+}
+
+// writeBank writes the genomes of all living organisms to the given file, if
+// any, as a genome bank
+func writeBank(sim *simulation.Simulation, path string) {
+	if path == "" {
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := sim.WriteBank(file); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nWrote %d genomes to %s\n", sim.OrganismCount(), path)
+}
+
+// writeNewick writes the surviving tree of life to the given file, if any
+func writeNewick(sim *simulation.Simulation, path string) {
+	if path == "" {
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := sim.WriteNewick(file); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nWrote the tree of life to %s\n", path)
+}
+
+// saveSimulation writes the simulation's state to the given file, if any
+func saveSimulation(sim *simulation.Simulation, path string) {
+	if path == "" {
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := sim.Save(file); err != nil {
+		log.Fatal(err)
 	}
+	fmt.Printf("\nSaved simulation at cycle %d to %s\n", sim.Cycle(), path)
 }
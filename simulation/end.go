@@ -0,0 +1,138 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+// EndCondition ends a simulation run once IsMet returns true. Name identifies
+// the condition when reporting why a run ended.
+type EndCondition struct {
+	Name  string
+	IsMet func(s *Simulation) bool
+}
+
+// NewEndCondition returns the end condition with the given name (one of the
+// End constants in config), using the limits set in the given globals. The
+// cycle, timeout and stagnation conditions return an error if their limit
+// isn't above zero, since they would either never end a run or end it at once.
+func NewEndCondition(name string, g *config.Globals) (EndCondition, error) {
+	var isMet func(s *Simulation) bool
+	switch name {
+	case config.EndMaxOrganisms:
+		isMet = func(s *Simulation) bool {
+			return s.OrganismCount() >= g.MaxOrganisms
+		}
+	case config.EndMaxCycles:
+		if g.MaxCycles <= 0 {
+			return EndCondition{}, limitError(name, "max_cycles", g.MaxCycles)
+		}
+		isMet = func(s *Simulation) bool {
+			return s.cycle+1 >= g.MaxCycles
+		}
+	case config.EndExtinction:
+		isMet = func(s *Simulation) bool {
+			return s.OrganismCount() == 0
+		}
+	case config.EndMinOrganisms:
+		isMet = func(s *Simulation) bool {
+			return s.OrganismCount() < g.MinOrganisms
+		}
+	case config.EndSingleLineage:
+		isMet = func(s *Simulation) bool {
+			return s.organismManager.LineageCount() <= 1
+		}
+	case config.EndTimeout:
+		if g.MaxRunSeconds <= 0 {
+			return EndCondition{}, limitError(name, "max_run_seconds", g.MaxRunSeconds)
+		}
+		timeout := time.Duration(g.MaxRunSeconds * float64(time.Second))
+		isMet = func(s *Simulation) bool {
+			return time.Since(s.startTime) >= timeout
+		}
+	case config.EndStagnation:
+		if g.StagnationCycles <= 0 {
+			return EndCondition{}, limitError(name, "stagnation_cycles", g.StagnationCycles)
+		}
+		isMet = func(s *Simulation) bool {
+			return s.cycle-s.organismManager.LastNewTreeCycle() >= g.StagnationCycles
+		}
+	default:
+		return EndCondition{}, fmt.Errorf("unknown end condition %q", name)
+	}
+	return EndCondition{Name: name, IsMet: isMet}, nil
+}
+
+func limitError(name, setting string, limit interface{}) error {
+	return fmt.Errorf("end condition %q needs %s above 0, got %v", name, setting, limit)
+}
+
+// buildEndConditions returns the end conditions listed in the globals, or in
+// the options if given there. Setting a max cycle count or timeout in the
+// options overrides the value in the globals and enables that end condition.
+// It returns an error if any condition is unknown or has no usable limit.
+func buildEndConditions(options *config.Options, globals *config.Globals) ([]EndCondition, error) {
+	// conditions use their own copy of the globals, so the overrides don't
+	// change the globals shared with the rest of the simulation
+	g := *globals
+	names := append([]string(nil), g.EndConditions...)
+	if options.EndConditions != "" {
+		names = strings.Split(options.EndConditions, ",")
+	}
+	if options.MaxCycles > 0 {
+		g.MaxCycles = options.MaxCycles
+		names = appendIfMissing(names, config.EndMaxCycles)
+	}
+	if options.Timeout > 0 {
+		g.MaxRunSeconds = options.Timeout.Seconds()
+		names = appendIfMissing(names, config.EndTimeout)
+	}
+
+	conditions := make([]EndCondition, 0, len(names))
+	for _, name := range names {
+		condition, err := NewEndCondition(strings.TrimSpace(name), &g)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+func appendIfMissing(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+// AddEndCondition adds an end condition to those checked by IsDone
+func (s *Simulation) AddEndCondition(condition EndCondition) {
+	s.endConditions = append(s.endConditions, condition)
+}
+
+// IsDone returns true once any end condition has been met, and records the
+// name of the first one met as the EndReason
+func (s *Simulation) IsDone() bool {
+	if s.endReason != "" {
+		return true
+	}
+	for _, condition := range s.endConditions {
+		if condition.IsMet(s) {
+			s.endReason = condition.Name
+			return true
+		}
+	}
+	return false
+}
+
+// EndReason returns the name of the end condition that ended the run, or an
+// empty string if none has been met
+func (s *Simulation) EndReason() string {
+	return s.endReason
+}
@@ -0,0 +1,122 @@
+package simulation
+
+import (
+	"strings"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/metrics"
+	"github.com/Zebbeni/protozoa/organism"
+)
+
+// traitMetrics lists every numeric organism trait to record in metrics
+var traitMetrics = []struct {
+	name  string
+	value func(t organism.Traits) float64
+}{
+	{"max_size", func(t organism.Traits) float64 { return t.MaxSize }},
+	{"spawn_health", func(t organism.Traits) float64 { return t.SpawnHealth }},
+	{"min_health_to_spawn", func(t organism.Traits) float64 { return t.MinHealthToSpawn }},
+	{"min_cycles_between_spawns", func(t organism.Traits) float64 { return float64(t.MinCyclesBetweenSpawns) }},
+	{"chance_to_mutate_decision_tree", func(t organism.Traits) float64 { return t.ChanceToMutateDecisionTree }},
+	{"ideal_ph", func(t organism.Traits) float64 { return t.IdealPh }},
+	{"ph_tolerance", func(t organism.Traits) float64 { return t.PhTolerance }},
+	{"ph_effect", func(t organism.Traits) float64 { return t.PhEffect }},
+}
+
+// metricsActions returns all actions to count in metrics, including
+// spawning and changing each memory flag in use
+func metricsActions(g *config.Globals) []d.Action {
+	flags := d.MemoryFlags(g)
+	actions := append([]d.Action{}, d.Actions[:]...)
+	actions = append(actions, d.ActSpawn)
+	actions = append(actions, d.FlagSetActions[:flags]...)
+	return append(actions, d.FlagClearActions[:flags]...)
+}
+
+// SetMetricsWriter sets a writer to receive a metrics Sample every
+// PopulationUpdateInterval cycles
+func (s *Simulation) SetMetricsWriter(w metrics.Writer) {
+	s.metricsWriter = w
+	s.lastSampleCreated = s.organismManager.CreatedCount()
+	s.lastSampleDead = s.organismManager.DeadCount()
+}
+
+// MetricsError returns the first error encountered writing metrics, after
+// which no more metrics are written
+func (s *Simulation) MetricsError() error {
+	return s.metricsErr
+}
+
+func (s *Simulation) recordMetrics() {
+	if s.metricsWriter == nil || s.metricsErr != nil {
+		return
+	}
+	if s.cycle%s.globals.PopulationUpdateInterval != 0 {
+		return
+	}
+	s.metricsErr = s.metricsWriter.Write(s.Sample())
+	s.lastSampleCreated = s.organismManager.CreatedCount()
+	s.lastSampleDead = s.organismManager.DeadCount()
+}
+
+// Sample returns summary statistics of the simulation's current state. Births
+// and deaths are counted since the last sample written to the metrics writer
+// (or since it was set), so calling Sample doesn't change later samples.
+func (s *Simulation) Sample() *metrics.Sample {
+	created, dead := s.organismManager.CreatedCount(), s.organismManager.DeadCount()
+	sample := &metrics.Sample{
+		Cycle:      s.cycle,
+		Population: s.OrganismCount(),
+		Food:       s.GetFoodCount(),
+		Dead:       dead,
+		Births:     created - s.lastSampleCreated,
+		Deaths:     dead - s.lastSampleDead,
+		MeanPh:     s.meanPh(),
+	}
+
+	traitValues := make([][]float64, len(traitMetrics))
+	actionCounts := make(map[d.Action]int)
+	treeSizeSum := 0
+	s.organismManager.ForEachOrganism(func(o *organism.Organism) {
+		traits := o.Traits()
+		for i, trait := range traitMetrics {
+			traitValues[i] = append(traitValues[i], trait.value(traits))
+		}
+		actionCounts[o.Action()]++
+		treeSizeSum += o.BrainSize()
+	})
+
+	for i, trait := range traitMetrics {
+		sample.Traits = append(sample.Traits, metrics.NewStat(trait.name, traitValues[i]))
+	}
+	if sample.Population > 0 {
+		sample.MeanTreeSize = float64(treeSizeSum) / float64(sample.Population)
+	}
+	for _, action := range metricsActions(s.globals) {
+		sample.Actions = append(sample.Actions, metrics.Count{
+			Name:  metricsName(d.Map[action]),
+			Count: actionCounts[action],
+		})
+	}
+	return sample
+}
+
+func (s *Simulation) meanPh() float64 {
+	sum, count := 0.0, 0
+	for _, column := range s.GetPhMap() {
+		for _, ph := range column {
+			sum += ph
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// metricsName converts a display name like "Move Ahead" to "move_ahead"
+func metricsName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
+}
@@ -1,14 +1,19 @@
 package simulation
 
 import (
-	"fmt"
 	d "github.com/Zebbeni/protozoa/decision"
 	"image/color"
+	"io"
+	"math/rand"
+	"os"
 	"time"
 
+	"github.com/Zebbeni/protozoa/brain"
 	"github.com/Zebbeni/protozoa/config"
 	"github.com/Zebbeni/protozoa/food"
+	"github.com/Zebbeni/protozoa/genealogy"
 	"github.com/Zebbeni/protozoa/manager"
+	"github.com/Zebbeni/protozoa/metrics"
 	"github.com/Zebbeni/protozoa/organism"
 	"github.com/Zebbeni/protozoa/utils"
 )
@@ -16,37 +21,141 @@ import (
 // Simulation contains a list of forces, particles, and drawing settings
 type Simulation struct {
 	options *config.Options
+	globals *config.Globals
 
 	cycle    int
 	isPaused bool
 
+	// random is the only source of randomness used by the simulation, so a
+	// given seed always reproduces the same run
+	random       *rand.Rand
+	randomSource *utils.RandomSource
+
 	selectedID int
 
+	endConditions []EndCondition
+	endReason     string
+	startTime     time.Time
+
 	organismManager    *manager.OrganismManager
 	foodManager        *manager.FoodManager
 	environmentManager *manager.EnvironmentManager
 
+	metricsWriter                     metrics.Writer
+	metricsErr                        error
+	lastSampleCreated, lastSampleDead int
+
 	// debug statistics
 	UpdateTime, EnvironmentUpdateTime, FoodUpdateTime, OrganismUpdateTime time.Duration
 	OrganismUpdateLoopTime, OrganismResolveLoopTime                       time.Duration
 }
 
+// Validate returns an error if NewSimulation would panic for the given
+// options and globals because a setting or end condition is unknown or has no
+// usable limit. Population and bank files aren't read.
+func Validate(options *config.Options, globals *config.Globals) error {
+	if err := globals.Validate(); err != nil {
+		return err
+	}
+	_, err := buildEndConditions(options, globals)
+	return err
+}
+
 // NewSimulation returns a simulation with generated world and organisms
 // cycle increments at the beginning of Update() so start at -1 to ensure
-// first actions are attributed to cycle 0
-func NewSimulation(options *config.Options) *Simulation {
+// first actions are attributed to cycle 0. Panics if any setting or configured
+// end condition is unknown, or the population file or genome bank can't be read.
+func NewSimulation(options *config.Options, globals *config.Globals) *Simulation {
+	if err := globals.Validate(); err != nil {
+		panic(err)
+	}
+	endConditions, err := buildEndConditions(options, globals)
+	if err != nil {
+		panic(err)
+	}
+	population, err := loadPopulation(options.PopulationFile, globals)
+	if err != nil {
+		panic(err)
+	}
+	bank, err := loadBank(options.BankFile)
+	if err != nil {
+		panic(err)
+	}
+	random, randomSource := utils.NewRand(int64(options.Seed))
 	sim := &Simulation{
-		options:  options,
-		cycle:    -1,
-		isPaused: false,
+		options:       options,
+		globals:       globals,
+		cycle:         -1,
+		isPaused:      false,
+		random:        random,
+		randomSource:  randomSource,
+		endConditions: endConditions,
+		startTime:     time.Now(),
+	}
+	sim.environmentManager = manager.NewEnvironmentManager(sim, sim.globals, sim.random)
+	sim.foodManager = manager.NewFoodManager(sim.globals, sim.random)
+	var genomes []*organism.Genome
+	if bank != nil {
+		if genomes, err = bank.Select(options.BankSelect, options.BankCount, sim.random); err != nil {
+			panic(err)
+		}
 	}
-	sim.environmentManager = manager.NewEnvironmentManager(sim)
-	sim.foodManager = manager.NewFoodManager()
-	sim.organismManager = manager.NewOrganismManager(sim)
+	sim.organismManager = manager.NewOrganismManager(sim, sim.globals, population, genomes, sim.random)
+	sim.organismManager.SetWorkerCount(options.Workers)
 
 	return sim
 }
 
+// loadPopulation reads the population file, if given
+func loadPopulation(path string, globals *config.Globals) (*organism.Population, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return organism.LoadPopulation(file, globals)
+}
+
+// loadBank reads the genome bank file, if given
+func loadBank(path string) (*organism.Bank, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return organism.LoadBank(file)
+}
+
+// WriteBank writes the genomes of all living organisms to w as a genome bank
+// in JSON format, which can seed another simulation
+func (s *Simulation) WriteBank(w io.Writer) error {
+	return s.organismManager.Bank(s.cycle).Write(w)
+}
+
+// WriteNewick writes the surviving tree of life to w in Newick format, with
+// decision tree mutations marked on its branches
+func (s *Simulation) WriteNewick(w io.Writer) error {
+	return s.organismManager.Genealogy().WriteNewick(w)
+}
+
+// GetMortality returns the number of deaths by cause, in total and within
+// each original ancestor's lineage
+func (s *Simulation) GetMortality() *genealogy.Mortality {
+	return s.organismManager.Mortality()
+}
+
+// GetGenealogyRecord returns the parent, birth and death of an organism, and
+// false if it isn't recorded (or was pruned as part of an extinct branch)
+func (s *Simulation) GetGenealogyRecord(id int) (genealogy.Record, bool) {
+	return s.organismManager.Genealogy().Get(id)
+}
+
 // Update calls Update functions for controllers in simulation
 func (s *Simulation) Update() {
 	if s.isPaused {
@@ -59,6 +168,7 @@ func (s *Simulation) Update() {
 	s.updateEnvironment()
 	s.updateFood()
 	s.updateOrganisms()
+	s.recordMetrics()
 
 	s.UpdateTime = time.Since(start)
 }
@@ -83,20 +193,16 @@ func (s *Simulation) updateOrganisms() {
 	s.OrganismResolveLoopTime = s.organismManager.ResolveDuration
 }
 
-// IsDone returns true if end condition met
-func (s *Simulation) IsDone() bool {
-	if s.GetNumOrganisms() >= config.MaxOrganisms() {
-		fmt.Printf("\nSimulation ended with %d organisms alive.", config.MaxOrganisms())
-		return true
-	}
-	return false
-}
-
 // IsDebug returns true if debug flag set on run
 func (s *Simulation) IsDebug() bool {
 	return s.options.IsDebugging
 }
 
+// Globals returns the config globals used by the simulation
+func (s *Simulation) Globals() *config.Globals {
+	return s.globals
+}
+
 // Cycle returns the current simulation cycle number
 func (s *Simulation) Cycle() int {
 	return s.cycle
@@ -157,12 +263,64 @@ func (s *Simulation) GetOrganismTraitsByID(id int) (organism.Traits, bool) {
 	return s.organismManager.GetOrganismTraitsByID(id)
 }
 
+// GetOrganismBrainByID returns a copy of the brain of the given organism (nil
+// if no organism found)
+func (s *Simulation) GetOrganismBrainByID(id int) brain.Brain {
+	return s.organismManager.GetOrganismBrainByID(id)
+}
+
 // GetOrganismDecisionTreeByID returns a copy of the currently-used decision tree of the
-// given organism (nil if no organism found)
+// given organism (nil if no organism found, or its brain isn't a decision tree)
 func (s *Simulation) GetOrganismDecisionTreeByID(id int) *d.Tree {
 	return s.organismManager.GetOrganismDecisionTreeByID(id)
 }
 
+// ExportOrganismTree returns the decision tree of the given organism with its
+// visit counts and last path, and the stats of every organism that has used
+// it, to write with decision.WriteDOT or decision.WriteJSON. Returns false if
+// no organism with a decision tree is found.
+func (s *Simulation) ExportOrganismTree(id int) (d.Export, bool) {
+	tree, ok := s.GetOrganismBrainByID(id).(*brain.Tree)
+	if !ok {
+		return d.Export{}, false
+	}
+	stats, _ := s.GetTreeStats(tree.ID)
+	trace := tree.Trace()
+	return d.Export{Tree: tree.CopyTree(), Stats: stats, Visits: trace.Visits, Path: trace.Path}, true
+}
+
+// GetTreeLibrary returns every decision tree seen in the simulation, with
+// stats on how well organisms using each have done. The library must not be
+// modified.
+func (s *Simulation) GetTreeLibrary() d.Library {
+	return s.organismManager.TreeLibrary()
+}
+
+// GetTopTrees returns the library entries of up to n of the decision trees
+// with the most living carriers, from most to fewest, which must not be
+// modified
+func (s *Simulation) GetTopTrees(n int) []*d.Entry {
+	entries := s.organismManager.TreeLibrary().Sorted(d.ByCarriers)
+	top := make([]*d.Entry, 0, n)
+	for _, entry := range entries {
+		if len(top) == n || entry.Stats.Carriers == 0 {
+			break
+		}
+		top = append(top, entry)
+	}
+	return top
+}
+
+// GetTreeStats returns the stats of the decision tree with the given ID and
+// whether the tree was found
+func (s *Simulation) GetTreeStats(id string) (d.Stats, bool) {
+	entry, ok := s.organismManager.TreeLibrary()[id]
+	if !ok {
+		return d.Stats{}, false
+	}
+	return entry.Stats, true
+}
+
 // GetHistory returns the full population history of all original ancestors as a
 // map of cycles to maps of ancestorIDs to the living descendants at that time
 func (s *Simulation) GetHistory() map[int]map[int]int16 {
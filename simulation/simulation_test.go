@@ -0,0 +1,687 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/genealogy"
+	"github.com/Zebbeni/protozoa/organism"
+)
+
+// testGlobals returns a small world based on the default settings
+func testGlobals() *config.Globals {
+	return &config.Globals{
+		GridUnitSize:  5,
+		GridWidth:     300,
+		GridHeight:    250,
+		GridUnitsWide: 60,
+		GridUnitsHigh: 50,
+
+		PopulationUpdateInterval: 100,
+
+		InitialOrganisms:    200,
+		InitialFood:         500,
+		ChanceToAddFoodItem: 0.1,
+		MaxFoodValue:        100,
+		MinFoodValue:        2,
+		MinPh:               0.0,
+		MaxPh:               10.0,
+		MinInitialPh:        4.0,
+		MaxInitialPh:        6.0,
+
+		MaxCyclesBetweenSpawns:        100,
+		MinSpawnHealth:                1,
+		MaxSpawnHealthPercent:         0.5,
+		MinOrganisms:                  20,
+		MaxOrganisms:                  2000,
+		ReseedStrategy:                config.ReseedNone,
+		ReproductionMode:              config.ReproduceAsexual,
+		GrowthFactor:                  0.5,
+		MaximumMaxSize:                100,
+		MinimumMaxSize:                10,
+		InitialDecisionTreeMutations:  5,
+		MinChanceToMutateDecisionTree: 0.01,
+		MaxChanceToMutateDecisionTree: 1.0,
+		MaxDecisionTreeSize:           32,
+		MinIdealPh:                    1.0,
+		MaxIdealPh:                    9.0,
+		MinPhTolerance:                0.7,
+		MaxPhTolerance:                0.9,
+		MaxOrganismPhEffect:           0.02,
+		PhIncrementToDisplay:          0.1,
+		PhDiffuseFactor:               0.2,
+
+		BrainType:                 config.BrainTree,
+		MutationCountDistribution: config.MutationCountFixed,
+
+		HealthChangeFromChemosynthesis:  0.01,
+		HealthChangeFromTurning:         -0.001,
+		HealthChangeFromMoving:          -0.01,
+		HealthChangeFromEatingAttempt:   -0.001,
+		HealthChangeFromAttacking:       -0.05,
+		HealthChangeInflictedByAttack:   -1.0,
+		HealthChangeFromFeeding:         -0.01,
+		HealthChangePerDecisionTreeNode: -0.0001,
+		HealthChangePerCycleUnhealthyPh: -0.02,
+
+		ActionResolutionMode: config.ResolveOrdered,
+		ConflictRule:         config.ConflictRandom,
+	}
+}
+
+func runCycles(sim *Simulation, cycles int) {
+	for i := 0; i < cycles; i++ {
+		sim.Update()
+	}
+}
+
+// decodeState saves a simulation and decodes the result, allowing two
+// simulations' full states to be compared
+func decodeState(t *testing.T, sim *Simulation) snapshot {
+	var buffer bytes.Buffer
+	if err := sim.Save(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	var snap snapshot
+	if err := gob.NewDecoder(&buffer).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	return snap
+}
+
+func TestSameSeedReproducesRun(t *testing.T) {
+	first := NewSimulation(&config.Options{Seed: 7}, testGlobals())
+	second := NewSimulation(&config.Options{Seed: 7}, testGlobals())
+	runCycles(first, 300)
+	runCycles(second, 300)
+
+	assert.Equal(t, decodeState(t, first), decodeState(t, second))
+}
+
+// TestWorkerCountDoesNotChangeRun runs each configuration that changes how
+// organisms decide, act or reproduce with one worker and with several, which
+// must give identical runs
+func TestWorkerCountDoesNotChangeRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(g *config.Globals)
+	}{
+		{"ordered", func(g *config.Globals) {}},
+		{"simultaneous random", func(g *config.Globals) {
+			g.ActionResolutionMode, g.ConflictRule = config.ResolveSimultaneous, config.ConflictRandom
+		}},
+		{"simultaneous bigger", func(g *config.Globals) {
+			g.ActionResolutionMode, g.ConflictRule = config.ResolveSimultaneous, config.ConflictBigger
+		}},
+		{"simultaneous split", func(g *config.Globals) {
+			g.ActionResolutionMode, g.ConflictRule = config.ResolveSimultaneous, config.ConflictSplit
+		}},
+		{"crossover", func(g *config.Globals) {
+			g.ReproductionMode = config.ReproduceCrossover
+		}},
+		{"mixed brains", func(g *config.Globals) {
+			g.BrainType, g.ReproductionMode = config.BrainMixed, config.ReproduceCrossover
+			g.NetworkHiddenNodes, g.NetworkMutationRate, g.NetworkMutationSize = 4, 0.1, 0.3
+		}},
+	}
+	for _, test := range tests {
+		globals := testGlobals()
+		test.configure(globals)
+		sequential := NewSimulation(&config.Options{Seed: 5, Workers: 1}, globals)
+		parallel := NewSimulation(&config.Options{Seed: 5, Workers: 4}, globals)
+		runCycles(sequential, 300)
+		runCycles(parallel, 300)
+
+		assert.Equal(t, decodeState(t, sequential), decodeState(t, parallel), test.name)
+	}
+}
+
+func TestSampleCountsEveryAction(t *testing.T) {
+	for _, flags := range []int{0, 2} {
+		globals := testGlobals()
+		globals.MemoryFlags = flags
+		sim := NewSimulation(&config.Options{Seed: 3}, globals)
+		runCycles(sim, 100)
+
+		sample := sim.Sample()
+		assert.Equal(t, sample, sim.Sample(), "sampling shouldn't change later samples")
+		assert.Len(t, sample.Actions, len(d.Actions)+1+2*flags)
+		counted := 0
+		for _, count := range sample.Actions {
+			counted += count.Count
+		}
+		assert.Equal(t, sim.OrganismCount(), counted, flags)
+	}
+}
+
+func TestSaveAndLoadResumesRun(t *testing.T) {
+	opts := &config.Options{Seed: 3}
+	original := NewSimulation(opts, testGlobals())
+	runCycles(original, 200)
+
+	var saved bytes.Buffer
+	if err := original.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Load(&saved, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runCycles(original, 200)
+	runCycles(restored, 200)
+
+	assert.Equal(t, original.Cycle(), restored.Cycle())
+	assert.Equal(t, decodeState(t, original), decodeState(t, restored))
+}
+
+func TestSimulationsWithDifferentGlobals(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		unitsWide, unitsHigh int
+		initialOrganisms     int
+	}{
+		{"small", 30, 20, 50},
+		{"wide", 120, 40, 300},
+		{"tall", 40, 90, 150},
+	}
+
+	sims := make([]*Simulation, len(testCases))
+	for i, tc := range testCases {
+		globals := testGlobals()
+		globals.GridUnitsWide = tc.unitsWide
+		globals.GridUnitsHigh = tc.unitsHigh
+		globals.InitialOrganisms = tc.initialOrganisms
+		sims[i] = NewSimulation(&config.Options{}, globals)
+	}
+
+	// run all simulations side by side, one cycle at a time
+	for cycle := 0; cycle < 100; cycle++ {
+		for _, sim := range sims {
+			sim.Update()
+		}
+	}
+
+	for i, tc := range testCases {
+		phMap := sims[i].GetPhMap()
+		assert.Len(t, phMap, tc.unitsWide, tc.name)
+		assert.Len(t, phMap[0], tc.unitsHigh, tc.name)
+		assert.Equal(t, 99, sims[i].Cycle(), tc.name)
+	}
+}
+
+func TestEndConditions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		endConditions  []string
+		options        config.Options
+		expectedReason string
+		expectedCycle  int
+	}{
+		{"max cycles", []string{config.EndMaxCycles}, config.Options{}, config.EndMaxCycles, 49},
+		{"max cycles option", []string{config.EndMaxOrganisms}, config.Options{MaxCycles: 30}, config.EndMaxCycles, 29},
+		{"stagnation", []string{config.EndStagnation}, config.Options{}, config.EndStagnation, 9},
+		{"min organisms", nil, config.Options{EndConditions: "min_organisms, max_cycles"}, config.EndMinOrganisms, -1},
+	}
+
+	for _, tc := range testCases {
+		globals := testGlobals()
+		globals.EndConditions = tc.endConditions
+		globals.MaxCycles = 50
+		globals.MinOrganisms = globals.InitialOrganisms + 1
+		// no mutations, so no new decision trees appear after the first cycle
+		globals.MinChanceToMutateDecisionTree = 0
+		globals.MaxChanceToMutateDecisionTree = 0
+		globals.StagnationCycles = 10
+
+		sim := NewSimulation(&tc.options, globals)
+		for !sim.IsDone() {
+			sim.Update()
+		}
+		assert.Equal(t, tc.expectedReason, sim.EndReason(), tc.name)
+		assert.Equal(t, tc.expectedCycle, sim.Cycle(), tc.name)
+	}
+}
+
+func TestUnknownEndCondition(t *testing.T) {
+	assert.Panics(t, func() {
+		NewSimulation(&config.Options{EndConditions: "forever"}, testGlobals())
+	})
+
+	// conditions enabled without a limit would never end a run, or end it
+	// at once
+	for _, name := range []string{config.EndMaxCycles, config.EndTimeout, config.EndStagnation} {
+		globals := testGlobals()
+		globals.MaxCycles, globals.MaxRunSeconds, globals.StagnationCycles = 0, 0, -1
+		_, err := NewEndCondition(name, globals)
+		assert.Error(t, err, name)
+
+		_, err = buildEndConditions(&config.Options{EndConditions: name}, globals)
+		assert.Error(t, err, name)
+
+		globals.EndConditions = []string{name}
+		_, err = buildEndConditions(&config.Options{}, globals)
+		assert.Error(t, err, name)
+		assert.Panics(t, func() { NewSimulation(&config.Options{}, globals) }, name)
+	}
+}
+
+func TestUnknownSettingValue(t *testing.T) {
+	globals := testGlobals()
+	globals.ConflictRule = "bigest"
+	assert.Panics(t, func() { NewSimulation(&config.Options{}, globals) })
+
+	// a snapshot saved with an unknown value can't be loaded either
+	sim := NewSimulation(&config.Options{}, testGlobals())
+	sim.globals.ReseedStrategy = "randm"
+	var saved bytes.Buffer
+	assert.NoError(t, sim.Save(&saved))
+	_, err := Load(&saved, &config.Options{})
+	assert.Error(t, err)
+}
+
+func TestReseedKeepsMinimumPopulation(t *testing.T) {
+	strategies := []string{config.ReseedRandom, config.ReseedSurvivor, config.ReseedArchive}
+	for _, strategy := range strategies {
+		globals := testGlobals()
+		globals.MinOrganisms = 100
+		globals.ReseedStrategy = strategy
+		globals.ReseedArchiveSize = 10
+		// organisms starve quickly without reseeding
+		globals.HealthChangePerDecisionTreeNode = -0.05
+
+		sim := NewSimulation(&config.Options{Seed: 9}, globals)
+		for cycle := 0; cycle < 100; cycle++ {
+			sim.Update()
+			assert.GreaterOrEqual(t, sim.OrganismCount(), globals.MinOrganisms, strategy)
+		}
+		assert.Greater(t, sim.organismManager.ReseedCount(), 0, strategy)
+
+		// clones of archived organisms are the only ones born to parents that
+		// had already died, so archive reseeds can't have fallen back to
+		// random organisms every time
+		archiveClones := 0
+		records := sim.organismManager.Genealogy().Records()
+		for _, record := range records {
+			if parent, ok := records[record.Parent]; ok && !parent.IsAlive() && parent.Died < record.Born {
+				archiveClones++
+			}
+		}
+		if strategy == config.ReseedArchive {
+			assert.Greater(t, archiveClones, 0, strategy)
+		} else {
+			assert.Equal(t, 0, archiveClones, strategy)
+		}
+
+		// the archive is restored along with everything else
+		var saved bytes.Buffer
+		if err := sim.Save(&saved); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(&saved, &config.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		runCycles(sim, 20)
+		runCycles(loaded, 20)
+		assert.Equal(t, decodeState(t, sim), decodeState(t, loaded), strategy)
+	}
+}
+
+func TestTreeLibraryTracksCarriers(t *testing.T) {
+	sim := NewSimulation(&config.Options{Seed: 4}, testGlobals())
+	runCycles(sim, 200)
+
+	carriers, births, deaths := 0, 0, 0
+	for _, entry := range sim.GetTreeLibrary() {
+		carriers += entry.Stats.Carriers
+		births += entry.Stats.Births
+		deaths += entry.Stats.Deaths
+	}
+	assert.Equal(t, sim.OrganismCount(), carriers)
+	assert.Equal(t, sim.organismManager.CreatedCount(), births)
+	assert.Equal(t, sim.GetDeadCount(), deaths)
+
+	sim.organismManager.ForEachOrganism(func(o *organism.Organism) {
+		stats, ok := sim.GetTreeStats(o.DecisionTreeID())
+		assert.True(t, ok)
+		assert.Greater(t, stats.Carriers, 0)
+	})
+
+	top := sim.GetTopTrees(5)
+	assert.Len(t, top, 5)
+	for i := 1; i < len(top); i++ {
+		assert.GreaterOrEqual(t, top[i-1].Stats.Carriers, top[i].Stats.Carriers)
+	}
+	// trees without living carriers are left out
+	all := sim.GetTopTrees(len(sim.GetTreeLibrary()))
+	assert.Less(t, len(all), len(sim.GetTreeLibrary()))
+	assert.Greater(t, all[len(all)-1].Stats.Carriers, 0)
+
+	// trees extinct for long enough are evicted, checked every population
+	// update
+	globals := testGlobals()
+	globals.TreeLibraryEvictionCycles = 50
+	evicting := NewSimulation(&config.Options{Seed: 4}, globals)
+	runCycles(evicting, 200)
+	assert.Less(t, len(evicting.GetTreeLibrary()), len(sim.GetTreeLibrary()))
+	for _, entry := range evicting.GetTreeLibrary() {
+		if entry.Stats.Carriers == 0 {
+			assert.Less(t, evicting.Cycle()-entry.Stats.ExtinctSince, globals.TreeLibraryEvictionCycles+globals.PopulationUpdateInterval)
+		}
+	}
+}
+
+func TestCarriersShareDecisionTrees(t *testing.T) {
+	sim := NewSimulation(&config.Options{Seed: 4}, testGlobals())
+	runCycles(sim, 200)
+
+	shared := make(map[string]*d.Tree)
+	sim.organismManager.ForEachOrganism(func(o *organism.Organism) {
+		tree := o.DecisionTree()
+		if first, ok := shared[tree.ID]; ok {
+			assert.Same(t, first, tree)
+		}
+		shared[tree.ID] = tree
+	})
+	assert.Less(t, len(shared), sim.OrganismCount())
+}
+
+func TestSaveAndLoadMixedBrains(t *testing.T) {
+	globals := testGlobals()
+	globals.BrainType = config.BrainMixed
+	globals.NetworkHiddenNodes = 4
+	globals.NetworkMutationRate = 0.1
+	globals.NetworkMutationSize = 0.3
+	opts := &config.Options{Seed: 9}
+	sim := NewSimulation(opts, globals)
+	runCycles(sim, 200)
+
+	var saved bytes.Buffer
+	if err := sim.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Load(&saved, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runCycles(sim, 100)
+	runCycles(restored, 100)
+	assert.Equal(t, decodeState(t, sim), decodeState(t, restored))
+}
+
+func writePopulation(t *testing.T, text string) string {
+	path := filepath.Join(t.TempDir(), "population.json")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPopulationFile(t *testing.T) {
+	path := writePopulation(t, `{"groups": [
+		{
+			"name": "forager",
+			"count": 30,
+			"tree": "(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))",
+			"traits": {"max_size": 40, "spawn_health": {"min": 4, "max": 8}, "color": "#40c040"},
+			"region": {"x": 0, "y": 0, "width": 20, "height": 50}
+		},
+		{"name": "random", "count": 5}
+	]}`)
+	globals := testGlobals()
+	globals.InitialOrganisms = 10
+	sim := NewSimulation(&config.Options{Seed: 2, PopulationFile: path}, globals)
+	assert.Equal(t, 45, sim.OrganismCount())
+
+	for id := 0; id < 30; id++ {
+		info := sim.GetOrganismInfoByID(id)
+		assert.Equal(t, 0, info.AncestorID)
+		assert.Less(t, info.Location.X, 20)
+		tree := sim.GetOrganismDecisionTreeByID(id)
+		assert.Equal(t, "(IfFoodAhead Eat (IfCanMoveAhead MoveAhead TurnLeft))", tree.String())
+		traits, _ := sim.GetOrganismTraitsByID(id)
+		assert.Equal(t, 40.0, traits.MaxSize)
+		assert.GreaterOrEqual(t, traits.SpawnHealth, 4.0)
+		assert.LessOrEqual(t, traits.SpawnHealth, 8.0)
+		assert.Equal(t, "#40c040", traits.OrganismColor.Hex())
+	}
+	assert.Contains(t, sim.GetAncestorColors(), 0)
+
+	// seeded runs are reproducible like any other
+	same := NewSimulation(&config.Options{Seed: 2, PopulationFile: path}, globals)
+	runCycles(sim, 100)
+	runCycles(same, 100)
+	assert.Equal(t, decodeState(t, sim), decodeState(t, same))
+}
+
+func TestInvalidPopulationFile(t *testing.T) {
+	invalid := []string{
+		`{"groups": [{"count": 1, "tree": "(IfFoodAhead Eat)"}]}`,
+		`{"groups": [{"count": 1, "region": {"x": 50, "y": 0, "width": 20, "height": 10}}]}`,
+		`{"groups": [{"count": 1, "traits": {"max_size": {"min": 5}}}]}`,
+		`{"groups": [{"count": 1, "traits": {"size": 5}}]}`,
+		`{"groups": [{"count": -1}]}`,
+	}
+	for _, text := range invalid {
+		path := writePopulation(t, text)
+		assert.Panics(t, func() {
+			NewSimulation(&config.Options{PopulationFile: path}, testGlobals())
+		}, text)
+	}
+}
+
+// writeBank runs a mixed-brain simulation and writes its genome bank to a file
+func writeBank(t *testing.T) (string, *organism.Bank) {
+	globals := testGlobals()
+	globals.BrainType = config.BrainMixed
+	globals.NetworkHiddenNodes = 3
+	sim := NewSimulation(&config.Options{Seed: 4}, globals)
+	runCycles(sim, 200)
+
+	var buffer bytes.Buffer
+	if err := sim.WriteBank(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "bank.json")
+	if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bank, err := organism.LoadBank(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, organism.BankVersion, bank.Version)
+	assert.Equal(t, sim.Cycle(), bank.Cycle)
+	assert.Len(t, bank.Genomes, sim.OrganismCount())
+
+	carriers := 0
+	for _, tree := range bank.Trees {
+		stats, _ := sim.GetTreeStats(tree.ID)
+		assert.Equal(t, stats.Carriers, tree.Organisms)
+		carriers += tree.Organisms
+	}
+	trees, networks := 0, 0
+	for _, genome := range bank.Genomes {
+		if genome.Tree != "" {
+			trees++
+		} else if genome.Network != nil {
+			networks++
+		}
+	}
+	assert.Equal(t, carriers, trees)
+	assert.Equal(t, len(bank.Genomes), trees+networks)
+	assert.NotZero(t, networks)
+	return path, bank
+}
+
+func TestGenomeBank(t *testing.T) {
+	path, bank := writeBank(t)
+	top, err := bank.Select(organism.SelectTop, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	globals := testGlobals()
+	globals.InitialOrganisms = 0
+	options := &config.Options{Seed: 5, BankFile: path, BankSelect: organism.SelectTop, BankCount: 5}
+	sim := NewSimulation(options, globals)
+	assert.Equal(t, 5, sim.OrganismCount())
+	for id, genome := range top {
+		if id > 0 {
+			assert.GreaterOrEqual(t, top[id-1].Children, genome.Children)
+		}
+		info := sim.GetOrganismInfoByID(id)
+		assert.Equal(t, 0, info.Age)
+		assert.Equal(t, 0, info.Children)
+		traits, _ := sim.GetOrganismTraitsByID(id)
+		assert.Equal(t, genome.Traits.MaxSize, traits.MaxSize)
+		assert.Equal(t, genome.Traits.IdealPh, traits.IdealPh)
+		if genome.Tree != "" {
+			assert.Equal(t, genome.Tree, sim.GetOrganismDecisionTreeByID(id).String())
+		}
+	}
+
+	// sampled runs are reproducible like any other
+	options = &config.Options{Seed: 5, BankFile: path, BankSelect: organism.SelectSample, BankCount: 10}
+	sim = NewSimulation(options, globals)
+	same := NewSimulation(options, globals)
+	assert.Equal(t, 10, sim.OrganismCount())
+	runCycles(sim, 100)
+	runCycles(same, 100)
+	assert.Equal(t, decodeState(t, sim), decodeState(t, same))
+}
+
+func TestInvalidGenomeBank(t *testing.T) {
+	invalid := []string{
+		`{"version": 2, "genomes": []}`,
+		`{"version": 1, "genomes": [{"id": 1, "tree": "(IfFoodAhead Eat)"}]}`,
+		`{"version": 1, "genomes": [{"id": 1}]}`,
+		`{"version": 1, "genomes": [{"id": 1, "network": {"Flags": 0, "Hidden": [[1]], "Output": []}}]}`,
+		`{"version": 1, "genomes": [{"id": 1, "tree": "Eat", "size": 5}]}`,
+	}
+	for _, text := range invalid {
+		path := writePopulation(t, text)
+		assert.Panics(t, func() {
+			NewSimulation(&config.Options{BankFile: path}, testGlobals())
+		}, text)
+	}
+
+	path, _ := writeBank(t)
+	assert.Panics(t, func() {
+		NewSimulation(&config.Options{BankFile: path, BankSelect: "best"}, testGlobals())
+	})
+}
+
+func TestGenealogy(t *testing.T) {
+	globals := testGlobals()
+	sim := NewSimulation(&config.Options{Seed: 6}, globals)
+	runCycles(sim, 300)
+
+	living := sim.GetAllOrganismInfo()
+	assert.NotEmpty(t, living)
+	children := 0
+	for id, info := range living {
+		record, ok := sim.GetGenealogyRecord(id)
+		assert.True(t, ok, id)
+		assert.True(t, record.IsAlive())
+		assert.Equal(t, sim.Cycle()-info.Age, int(record.Born))
+		if record.Parent == genealogy.NoParent {
+			continue
+		}
+		children++
+		parent, ok := sim.GetGenealogyRecord(int(record.Parent))
+		assert.True(t, ok)
+		assert.LessOrEqual(t, parent.Born, record.Born)
+		if !parent.IsAlive() {
+			assert.NotEqual(t, genealogy.CauseNone, parent.Cause)
+			assert.GreaterOrEqual(t, parent.Died, parent.Born)
+		}
+	}
+	assert.NotZero(t, children)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, sim.WriteNewick(&buffer))
+	newick := buffer.String()
+	assert.True(t, strings.HasPrefix(newick, "("))
+	assert.True(t, strings.HasSuffix(newick, ");\n"))
+	assert.Equal(t, strings.Count(newick, "("), strings.Count(newick, ")"))
+	assert.Contains(t, newick, "[&&NHX:tree_mutation=1]")
+}
+
+func TestGenealogyMarksMutatedNetworks(t *testing.T) {
+	globals := testGlobals()
+	globals.BrainType = config.BrainNetwork
+	globals.NetworkHiddenNodes = 4
+	globals.NetworkMutationRate = 0.1
+	globals.NetworkMutationSize = 0.3
+	sim := NewSimulation(&config.Options{Seed: 6}, globals)
+	runCycles(sim, 300)
+
+	mutated, unmutated := 0, 0
+	for _, record := range sim.organismManager.Genealogy().Records() {
+		switch {
+		case record.Parent == genealogy.NoParent:
+		case record.Mutated:
+			mutated++
+		default:
+			unmutated++
+		}
+	}
+	assert.NotZero(t, mutated)
+	assert.NotZero(t, unmutated)
+}
+
+func TestMortality(t *testing.T) {
+	for _, mode := range []string{config.ResolveOrdered, config.ResolveSimultaneous} {
+		globals := testGlobals()
+		globals.ActionResolutionMode = mode
+		sim := NewSimulation(&config.Options{Seed: 7}, globals)
+		runCycles(sim, 300)
+
+		mortality := sim.GetMortality()
+		assert.Equal(t, sim.GetDeadCount(), genealogy.Sum(mortality.Total), mode)
+		assert.Zero(t, mortality.Total[genealogy.CauseNone], mode)
+		assert.NotZero(t, mortality.Total[genealogy.CauseStarvation], mode)
+		assert.NotZero(t, mortality.Total[genealogy.CauseAttack], mode)
+		assert.NotZero(t, mortality.Total[genealogy.CausePh], mode)
+
+		lineageDeaths := 0
+		for _, lineage := range mortality.Lineages {
+			lineageDeaths += genealogy.Sum(lineage)
+		}
+		assert.Equal(t, genealogy.Sum(mortality.Total), lineageDeaths, mode)
+
+		for id := 0; id < sim.GetDeadCount()+sim.OrganismCount(); id++ {
+			record, ok := sim.GetGenealogyRecord(id)
+			if !ok || record.IsAlive() {
+				continue
+			}
+			if record.Cause == genealogy.CauseAttack || record.Cause == genealogy.CauseFeeding {
+				assert.NotEqual(t, int32(genealogy.NoKiller), record.Killer, mode)
+				assert.NotEqual(t, int32(id), record.Killer, mode)
+			} else {
+				assert.Equal(t, int32(genealogy.NoKiller), record.Killer, mode)
+			}
+		}
+
+		// counts are kept across saves
+		var saved bytes.Buffer
+		if err := sim.Save(&saved); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(&saved, &config.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, mortality.Total, loaded.GetMortality().Total, mode)
+	}
+}
@@ -0,0 +1,99 @@
+package simulation
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+
+	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/manager"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// snapshotVersion should be incremented whenever the snapshot format changes
+// in a way that prevents older snapshots from being loaded
+const snapshotVersion = 16
+
+// snapshot contains everything needed to resume a simulation exactly where it
+// left off
+type snapshot struct {
+	Version int
+	Globals config.Globals
+	Cycle   int
+	// RandomState is the state of the simulation's random source
+	RandomState uint64
+
+	Environment manager.EnvironmentSnapshot
+	Food        manager.FoodSnapshot
+	Organisms   manager.OrganismSnapshot
+}
+
+func init() {
+	// decision tree nodes and ancestor colors are stored as interfaces
+	gob.Register(d.Action(0))
+	gob.Register(d.Condition(0))
+	gob.Register(colorful.Color{})
+}
+
+// Save writes the full state of the simulation to w. It should only be called
+// between calls to Update.
+func (s *Simulation) Save(w io.Writer) error {
+	snap := snapshot{
+		Version:     snapshotVersion,
+		Globals:     *s.globals,
+		Cycle:       s.cycle,
+		RandomState: s.randomSource.State,
+		Environment: s.environmentManager.Snapshot(),
+		Food:        s.foodManager.Snapshot(),
+		Organisms:   s.organismManager.Snapshot(),
+	}
+	if err := gob.NewEncoder(w).Encode(&snap); err != nil {
+		return fmt.Errorf("failed to write simulation snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a simulation previously written by Save and returns it, ready to
+// continue from the saved cycle. The saved config globals are used for the
+// restored simulation.
+func Load(r io.Reader, options *config.Options) (*Simulation, error) {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("failed to read simulation snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (expected %d)", snap.Version, snapshotVersion)
+	}
+
+	if err := snap.Globals.Validate(); err != nil {
+		return nil, err
+	}
+	endConditions, err := buildEndConditions(options, &snap.Globals)
+	if err != nil {
+		return nil, err
+	}
+
+	random, randomSource := utils.NewRand(0)
+	randomSource.State = snap.RandomState
+	sim := &Simulation{
+		options:       options,
+		globals:       &snap.Globals,
+		cycle:         snap.Cycle,
+		isPaused:      false,
+		selectedID:    -1,
+		random:        random,
+		randomSource:  randomSource,
+		endConditions: endConditions,
+		startTime:     time.Now(),
+	}
+	sim.environmentManager = manager.NewEnvironmentManagerFromSnapshot(sim, sim.globals, sim.random, snap.Environment)
+	sim.foodManager = manager.NewFoodManagerFromSnapshot(sim.globals, sim.random, snap.Food)
+	sim.organismManager = manager.NewOrganismManagerFromSnapshot(sim, sim.globals, sim.random, snap.Organisms)
+	sim.organismManager.SetWorkerCount(options.Workers)
+
+	return sim, nil
+}
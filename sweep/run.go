@@ -0,0 +1,228 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Zebbeni/protozoa/config"
+	"github.com/Zebbeni/protozoa/simulation"
+	"github.com/Zebbeni/protozoa/utils"
+)
+
+// run is a single simulation to perform as part of a sweep
+type run struct {
+	index       int
+	combination Combination
+	globals     *config.Globals
+	seed        int
+	trial       int
+	runSeed     int
+}
+
+// result contains the final summary statistics of a single run
+type result struct {
+	run       run
+	endReason string
+	cycles    int
+	organisms int
+	dead      int
+	food      int
+	lineages  int
+	runtime   time.Duration
+}
+
+// Progress is called after each row of a sweep is written, with the number
+// of runs finished so far, the total number of runs, and how the latest
+// finished run ended
+type Progress func(finished, total int, endReason string, cycles int)
+
+// Run performs every run described by the spec using the given base globals,
+// running up to workerCount simulations at once, and writes one CSV row per
+// run to w. Rows are written in a fixed order as soon as they're available,
+// and progress (if not nil) is called after each one. If a row can't be
+// written, Run returns the error without starting any more runs, leaving
+// those in progress to finish in the background.
+func Run(spec *Spec, base *config.Globals, workerCount int, w io.Writer, progress Progress) error {
+	runs, err := spec.runs(base)
+	if err != nil {
+		return err
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(spec.header()); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	// done is closed when Run returns, so the workers and the goroutine
+	// feeding them stop rather than wait for results that won't be read
+	done := make(chan struct{})
+	defer close(done)
+	jobs := make(chan run)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				select {
+				case results <- perform(r, spec.MaxCycles):
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
+		for _, r := range runs {
+			select {
+			case jobs <- r:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// write rows in run order, holding any that finish early
+	pending := make(map[int]result)
+	next := 0
+	for res := range results {
+		pending[res.run.index] = res
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if err := writer.Write(ready.row()); err != nil {
+				return err
+			}
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(next, len(runs), ready.endReason, ready.cycles)
+			}
+		}
+	}
+	return writer.Error()
+}
+
+// runs lists every run in the sweep. Trial 0 of each seed uses the seed
+// itself, and other trials derive a seed from it. Any run can be reproduced
+// outside the sweep by passing its run seed to -seed.
+func (s *Spec) runs(base *config.Globals) ([]run, error) {
+	combinations, err := s.Combinations()
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]run, 0, len(combinations)*len(s.Seeds)*s.Trials)
+	for _, combination := range combinations {
+		globals, err := s.Apply(base, combination)
+		if err != nil {
+			return nil, err
+		}
+		// check the combination up front, since a simulation that can't be
+		// created would otherwise panic in a worker
+		if err := simulation.Validate(runOptions(0, s.MaxCycles), globals); err != nil {
+			return nil, fmt.Errorf("invalid sweep values %v: %w", combination, err)
+		}
+		for _, seed := range s.Seeds {
+			for trial := 0; trial < s.Trials; trial++ {
+				runSeed := seed
+				if trial > 0 {
+					runSeed = int(utils.StreamSeed(int64(seed), trial) & math.MaxInt32)
+				}
+				runs = append(runs, run{
+					index:       len(runs),
+					combination: combination,
+					globals:     globals,
+					seed:        seed,
+					trial:       trial,
+					runSeed:     runSeed,
+				})
+			}
+		}
+	}
+	return runs, nil
+}
+
+// runOptions returns the options a sweep run is simulated with
+func runOptions(seed, maxCycles int) *config.Options {
+	return &config.Options{Seed: seed, Workers: 1, MaxCycles: maxCycles}
+}
+
+// perform runs a single simulation until it meets one of its configured end
+// conditions, goes extinct, or runs for maxCycles (if positive)
+func perform(r run, maxCycles int) result {
+	start := time.Now()
+	sim := simulation.NewSimulation(runOptions(r.runSeed, maxCycles), r.globals)
+	extinction, _ := simulation.NewEndCondition(config.EndExtinction, r.globals)
+	sim.AddEndCondition(extinction)
+	for !sim.IsDone() {
+		sim.Update()
+	}
+
+	lineages := make(map[int]bool)
+	for _, info := range sim.GetAllOrganismInfo() {
+		lineages[info.AncestorID] = true
+	}
+
+	return result{
+		run:       r,
+		endReason: sim.EndReason(),
+		cycles:    sim.Cycle() + 1,
+		organisms: sim.OrganismCount(),
+		dead:      sim.GetDeadCount(),
+		food:      sim.GetFoodCount(),
+		lineages:  len(lineages),
+		runtime:   time.Since(start),
+	}
+}
+
+func (s *Spec) header() []string {
+	header := make([]string, 0, len(s.Parameters)+10)
+	for _, parameter := range s.Parameters {
+		header = append(header, parameter.Name)
+	}
+	return append(header, "seed", "trial", "run_seed", "end_reason", "cycles",
+		"organisms", "dead", "food", "lineages", "runtime_seconds")
+}
+
+func (r result) row() []string {
+	row := make([]string, 0, len(r.run.combination)+10)
+	for _, value := range r.run.combination {
+		row = append(row, fmt.Sprint(value))
+	}
+	return append(row,
+		strconv.Itoa(r.run.seed),
+		strconv.Itoa(r.run.trial),
+		strconv.Itoa(r.run.runSeed),
+		r.endReason,
+		strconv.Itoa(r.cycles),
+		strconv.Itoa(r.organisms),
+		strconv.Itoa(r.dead),
+		strconv.Itoa(r.food),
+		strconv.Itoa(r.lineages),
+		strconv.FormatFloat(r.runtime.Seconds(), 'f', 3, 64),
+	)
+}
@@ -0,0 +1,124 @@
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+// Spec describes a parameter sweep: every combination of parameter values is
+// run once for each seed and trial.
+type Spec struct {
+	Parameters []Parameter `json:"parameters"`
+	Seeds      []int       `json:"seeds"`
+	Trials     int         `json:"trials"`
+	// MaxCycles ends any run that has neither gone extinct nor met one of
+	// its configured end conditions after this many cycles
+	MaxCycles int `json:"max_cycles"`
+}
+
+// Parameter lists the values to try for a single Globals field, referenced by
+// its json name. Values may be listed explicitly, or given as a range from
+// From to To (inclusive) in Steps evenly-spaced values.
+type Parameter struct {
+	Name   string        `json:"name"`
+	Values []interface{} `json:"values,omitempty"`
+	From   float64       `json:"from,omitempty"`
+	To     float64       `json:"to,omitempty"`
+	Steps  int           `json:"steps,omitempty"`
+}
+
+// Combination is a single set of parameter values, in the order the
+// parameters are listed in the Spec
+type Combination []interface{}
+
+// LoadSpec reads a sweep Spec in JSON format
+func LoadSpec(r io.Reader) (*Spec, error) {
+	spec := &Spec{Trials: 1}
+	if err := json.NewDecoder(r).Decode(spec); err != nil {
+		return nil, fmt.Errorf("failed to read sweep spec: %w", err)
+	}
+	if len(spec.Seeds) == 0 {
+		spec.Seeds = []int{0}
+	}
+	if spec.Trials < 1 {
+		spec.Trials = 1
+	}
+	return spec, nil
+}
+
+// values returns all values to try for the parameter
+func (p Parameter) values() ([]interface{}, error) {
+	if len(p.Values) > 0 {
+		return p.Values, nil
+	}
+	if p.Steps < 1 {
+		return nil, fmt.Errorf("parameter %s needs either values or a positive number of steps", p.Name)
+	}
+	if p.Steps == 1 {
+		return []interface{}{p.From}, nil
+	}
+	values := make([]interface{}, p.Steps)
+	stepSize := (p.To - p.From) / float64(p.Steps-1)
+	for i := range values {
+		// round away floating point noise so values print as expected
+		value := p.From + stepSize*float64(i)
+		values[i] = math.Round(value*1e12) / 1e12
+	}
+	return values, nil
+}
+
+// Combinations returns every combination of all parameters' values
+func (s *Spec) Combinations() ([]Combination, error) {
+	combinations := []Combination{{}}
+	for _, parameter := range s.Parameters {
+		values, err := parameter.values()
+		if err != nil {
+			return nil, err
+		}
+		expanded := make([]Combination, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, value := range values {
+				next := make(Combination, len(combination), len(combination)+1)
+				copy(next, combination)
+				expanded = append(expanded, append(next, value))
+			}
+		}
+		combinations = expanded
+	}
+	return combinations, nil
+}
+
+// Apply returns a copy of the base globals with the combination's values set
+// on the fields named by the spec's parameters. It returns an error if the
+// result has an unknown value for any setting (see config.Globals.Validate).
+func (s *Spec) Apply(base *config.Globals, combination Combination) (*config.Globals, error) {
+	data, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for i, parameter := range s.Parameters {
+		if _, ok := fields[parameter.Name]; !ok {
+			return nil, fmt.Errorf("unknown config parameter: %s", parameter.Name)
+		}
+		fields[parameter.Name] = combination[i]
+	}
+	if data, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+	globals := &config.Globals{}
+	if err := json.Unmarshal(data, globals); err != nil {
+		return nil, fmt.Errorf("invalid sweep values %v: %w", combination, err)
+	}
+	if err := globals.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid sweep values %v: %w", combination, err)
+	}
+	return globals, nil
+}
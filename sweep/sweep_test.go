@@ -0,0 +1,150 @@
+package sweep
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Zebbeni/protozoa/config"
+)
+
+func TestCombinations(t *testing.T) {
+	spec, err := LoadSpec(strings.NewReader(`{
+		"parameters": [
+			{"name": "health_change_per_decision_tree_node", "from": -0.001, "to": 0, "steps": 5},
+			{"name": "conflict_rule", "values": ["random", "bigger"]}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	combinations, err := spec.Combinations()
+	assert.NoError(t, err)
+	assert.Len(t, combinations, 10)
+	assert.Equal(t, Combination{-0.001, "random"}, combinations[0])
+	assert.Equal(t, Combination{-0.00075, "bigger"}, combinations[3])
+	assert.Equal(t, Combination{0.0, "bigger"}, combinations[9])
+}
+
+func TestApply(t *testing.T) {
+	spec := &Spec{Parameters: []Parameter{
+		{Name: "initial_organisms", Values: []interface{}{50.0}},
+		{Name: "conflict_rule", Values: []interface{}{"split"}},
+	}}
+	base := &config.Globals{
+		InitialOrganisms:          10,
+		MaxOrganisms:              100,
+		ReseedStrategy:            config.ReseedNone,
+		ReproductionMode:          config.ReproduceAsexual,
+		BrainType:                 config.BrainTree,
+		MutationCountDistribution: config.MutationCountFixed,
+		ActionResolutionMode:      config.ResolveOrdered,
+		ConflictRule:              config.ConflictRandom,
+	}
+
+	globals, err := spec.Apply(base, Combination{50.0, "split"})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, globals.InitialOrganisms)
+	assert.Equal(t, config.ConflictSplit, globals.ConflictRule)
+	assert.Equal(t, 100, globals.MaxOrganisms)
+	assert.Equal(t, 10, base.InitialOrganisms, "base globals should be unchanged")
+
+	// a misspelled value is rejected rather than falling back to another
+	_, err = spec.Apply(base, Combination{50.0, "splt"})
+	assert.Error(t, err)
+
+	spec.Parameters[0].Name = "not_a_parameter"
+	_, err = spec.Apply(base, Combination{50.0, "split"})
+	assert.Error(t, err)
+}
+
+// failingWriter accepts up to limit bytes, and fails any write past that
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		return 0, errors.New("disk full")
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+// testSweep returns a short sweep of small runs, and the default globals to
+// run it against
+func testSweep(t *testing.T) (*Spec, config.Globals) {
+	file, err := os.Open("../settings/default.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var base config.Globals
+	if err := json.NewDecoder(file).Decode(&base); err != nil {
+		t.Fatal(err)
+	}
+	base.InitialOrganisms = 20
+
+	spec := &Spec{
+		Parameters: []Parameter{{Name: "conflict_rule", Values: []interface{}{"random", "bigger"}}},
+		Seeds:      []int{1},
+		Trials:     4,
+		MaxCycles:  5,
+	}
+	return spec, base
+}
+
+func TestRunReportsProgress(t *testing.T) {
+	spec, base := testSweep(t)
+	var output bytes.Buffer
+	var finished []int
+	err := Run(spec, &base, 2, &output, func(done, total int, endReason string, cycles int) {
+		assert.Equal(t, 8, total)
+		assert.Equal(t, config.EndMaxCycles, endReason)
+		assert.Equal(t, 5, cycles)
+		finished = append(finished, done)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, finished)
+	assert.Equal(t, 9, strings.Count(output.String(), "\n"))
+}
+
+func TestRunRejectsUnusableCombination(t *testing.T) {
+	// stagnation can't end a run without a limit, so the sweep fails before
+	// starting any runs rather than panicking in a worker
+	spec, base := testSweep(t)
+	base.StagnationCycles = 0
+	spec.Parameters = append(spec.Parameters, Parameter{
+		Name:   "end_conditions",
+		Values: []interface{}{[]interface{}{config.EndStagnation}},
+	})
+	var output bytes.Buffer
+	err := Run(spec, &base, 2, &output, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "stagnation_cycles")
+	}
+	assert.Zero(t, output.Len())
+}
+
+func TestRunStopsAfterWriteError(t *testing.T) {
+	spec, base := testSweep(t)
+	header := strings.Join(spec.header(), ",") + "\n"
+
+	before := runtime.NumGoroutine()
+	err := Run(spec, &base, 2, &failingWriter{limit: len(header)}, nil)
+	assert.EqualError(t, err, "disk full")
+	// the workers and the goroutine feeding them stop once their runs end
+	for deadline := time.Now().Add(10 * time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+
+	err = Run(spec, &base, 2, &failingWriter{}, nil)
+	assert.EqualError(t, err, "disk full")
+}
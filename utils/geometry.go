@@ -29,10 +29,10 @@ var (
 )
 
 // GetRandomPoint returns a random point somewhere on the simulation grid
-func GetRandomPoint(width, height int) Point {
+func GetRandomPoint(width, height int, r *rand.Rand) Point {
 	return Point{
-		X: rand.Intn(width),
-		Y: rand.Intn(height),
+		X: r.Intn(width),
+		Y: r.Intn(height),
 	}
 }
 
@@ -49,13 +49,14 @@ func GetAllPointsNear(point Point, distance int) []Point {
 }
 
 // GetRandomDirection returns a point representing a random direction
-func GetRandomDirection() Point {
-	return Directions[rand.Intn(len(Directions))]
+func GetRandomDirection(r *rand.Rand) Point {
+	return Directions[r.Intn(len(Directions))]
 }
 
-// Add add a given Point and returns the result
+// Add add a given Point and returns the result (without wrapping it around
+// the grid)
 func (p Point) Add(toAdd Point) Point {
-	return Point{X: p.X + toAdd.X, Y: p.Y + toAdd.Y}.Wrap()
+	return Point{X: p.X + toAdd.X, Y: p.Y + toAdd.Y}
 }
 
 // Times multiplies a given value and returns the result
@@ -67,10 +68,10 @@ func (p *Point) Times(toMultiply int) Point {
 }
 
 // Wrap returns a point value after wrapping it around the grid
-func (p Point) Wrap() Point {
+func (p Point) Wrap(g *c.Globals) Point {
 	return Point{
-		X: (p.X + c.GridUnitsWide()) % c.GridUnitsWide(),
-		Y: (p.Y + c.GridUnitsHigh()) % c.GridUnitsHigh(),
+		X: (p.X + g.GridUnitsWide) % g.GridUnitsWide,
+		Y: (p.Y + g.GridUnitsHigh) % g.GridUnitsHigh,
 	}
 }
 
@@ -0,0 +1,43 @@
+package utils
+
+import "math/rand"
+
+// RandomSource is a small, fast rand.Source64 (SplitMix64) whose entire state
+// is a single exported value, so it can be saved and restored exactly.
+type RandomSource struct {
+	State uint64
+}
+
+// NewRand returns a *rand.Rand drawing from a new RandomSource seeded with
+// the given value, along with the source itself
+func NewRand(seed int64) (*rand.Rand, *RandomSource) {
+	source := &RandomSource{}
+	source.Seed(seed)
+	return rand.New(source), source
+}
+
+// Seed resets the source to a state determined by the given seed
+func (s *RandomSource) Seed(seed int64) {
+	s.State = uint64(seed)
+}
+
+// Uint64 returns the next pseudo-random 64-bit value
+func (s *RandomSource) Uint64() uint64 {
+	s.State += 0x9e3779b97f4a7c15
+	z := s.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next pseudo-random non-negative 63-bit value
+func (s *RandomSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// StreamSeed mixes a seed with a stream number, returning a new seed whose
+// sequence is independent of those of neighboring stream numbers
+func StreamSeed(seed int64, stream int) int64 {
+	source := RandomSource{State: uint64(seed) ^ (uint64(stream) * 0xd1342543de82ef95)}
+	return int64(source.Uint64())
+}
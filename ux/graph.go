@@ -17,6 +17,7 @@ const (
 
 type Graph struct {
 	simulation *s.Simulation
+	globals    *c.Globals
 	graphImage *ebiten.Image
 
 	maxTotalPopulation int
@@ -25,6 +26,7 @@ type Graph struct {
 func NewGraph(sim *s.Simulation) *Graph {
 	return &Graph{
 		simulation: sim,
+		globals:    sim.Globals(),
 		graphImage: nil,
 	}
 }
@@ -55,17 +57,17 @@ func (g *Graph) Render() *ebiten.Image {
 
 func (g *Graph) renderAll() *ebiten.Image {
 	// add 1 to make sure cycle 0 gives us a bar count of 1
-	barCount := 1 + (g.simulation.Cycle() / c.PopulationUpdateInterval())
+	barCount := 1 + (g.simulation.Cycle() / g.globals.PopulationUpdateInterval)
 	barWidth := realGraphWidth / float64(barCount)
 	g.maxTotalPopulation = g.getMaxPopulation()
 	img := ebiten.NewImage(realGraphWidth, realGraphHeight)
 
-	for cycle := 0; cycle <= g.simulation.Cycle(); cycle += c.PopulationUpdateInterval() {
+	for cycle := 0; cycle <= g.simulation.Cycle(); cycle += g.globals.PopulationUpdateInterval {
 		barImage, graphBarPopulation := g.renderGraphBar(cycle)
 		options := &ebiten.DrawImageOptions{}
 		scaleX := barWidth / float64(barImage.Bounds().Dx())
 		scaleY := float64(graphBarPopulation) / float64(g.maxTotalPopulation)
-		xOffset := float64(cycle/c.PopulationUpdateInterval()) * barWidth
+		xOffset := float64(cycle/g.globals.PopulationUpdateInterval) * barWidth
 		yOffset := realGraphHeight - (float64(barImage.Bounds().Dy()) * scaleY)
 		options.GeoM.Scale(scaleX, scaleY)
 		options.GeoM.Translate(xOffset, yOffset)
@@ -76,7 +78,7 @@ func (g *Graph) renderAll() *ebiten.Image {
 }
 
 func (g *Graph) renderNewBar() *ebiten.Image {
-	barCount := 1 + (g.simulation.Cycle() / c.PopulationUpdateInterval())
+	barCount := 1 + (g.simulation.Cycle() / g.globals.PopulationUpdateInterval)
 	barWidth := realGraphWidth / float64(barCount)
 	barImage, graphBarPopulation := g.renderGraphBar(g.simulation.Cycle())
 
@@ -114,14 +116,14 @@ func (g *Graph) renderNewBar() *ebiten.Image {
 // draw and return an image of the stacked graph bar for a single cycle
 // also return the number of
 func (g *Graph) renderGraphBar(cycle int) (*ebiten.Image, int) {
-	barCount := 1 + (g.simulation.Cycle() / c.PopulationUpdateInterval())
+	barCount := 1 + (g.simulation.Cycle() / g.globals.PopulationUpdateInterval)
 	realBarWidth := realGraphWidth / barCount
 
 	populationMap := g.simulation.GetHistory()
 	ancestorColorMap := g.simulation.GetAncestorColors()
 	sortedAncestorIDs := g.simulation.GetAncestorsSorted()
 
-	previousFamilyPopulations := populationMap[cycle-c.PopulationUpdateInterval()]
+	previousFamilyPopulations := populationMap[cycle-g.globals.PopulationUpdateInterval]
 	prevTotal := getTotalPopulation(previousFamilyPopulations)
 	newFamilyPopulations := populationMap[cycle]
 	newTotal := getTotalPopulation(newFamilyPopulations)
@@ -195,7 +197,7 @@ func getTotalPopulation(populationMap map[int]int16) int {
 
 func (g *Graph) getMaxPopulation() int {
 	maxTotal := int16(0)
-	for cycle := 0; cycle <= g.simulation.Cycle(); cycle += c.PopulationUpdateInterval() {
+	for cycle := 0; cycle <= g.simulation.Cycle(); cycle += g.globals.PopulationUpdateInterval {
 		total := g.getPopulationByCycle(cycle)
 		if total > maxTotal {
 			maxTotal = total
@@ -223,5 +225,5 @@ func (g *Graph) shouldRefresh() bool {
 }
 
 func (g *Graph) shouldAddBar() bool {
-	return g.simulation.Cycle()%c.PopulationUpdateInterval() == 0
+	return g.simulation.Cycle()%g.globals.PopulationUpdateInterval == 0
 }
@@ -57,6 +57,7 @@ var (
 
 type Grid struct {
 	simulation *simulation.Simulation
+	globals    *config.Globals
 
 	previousEnvImage  *ebiten.Image
 	previousFoodImage *ebiten.Image
@@ -70,13 +71,14 @@ type Grid struct {
 
 func NewGrid(simulation *simulation.Simulation) *Grid {
 	g := &Grid{
-		simulation:        simulation,
-		previousEnvImage:  newBlankLayer(),
-		previousFoodImage: newBlankLayer(),
-		previousOrgsImage: newBlankLayer(),
-		doRefresh:         true,
-		viewMode:          orgsPhMode,
+		simulation: simulation,
+		globals:    simulation.Globals(),
+		doRefresh:  true,
+		viewMode:   orgsPhMode,
 	}
+	g.previousEnvImage = g.newBlankLayer()
+	g.previousFoodImage = g.newBlankLayer()
+	g.previousOrgsImage = g.newBlankLayer()
 	loadOrganismImages()
 	return g
 }
@@ -90,11 +92,11 @@ func loadOrganismImages() {
 
 // Render draws all organisms and food on the simulation grid
 func (g *Grid) Render() *ebiten.Image {
-	envImage := newBlankLayer()
-	foodImage := newBlankLayer()
-	orgsImage := newBlankLayer()
-	selImage := newBlankLayer()
-	gridImage := newBlankLayer()
+	envImage := g.newBlankLayer()
+	foodImage := g.newBlankLayer()
+	orgsImage := g.newBlankLayer()
+	selImage := g.newBlankLayer()
+	gridImage := g.newBlankLayer()
 
 	g.renderEnvironment(envImage, g.doRefresh)
 	g.renderFood(foodImage, g.doRefresh)
@@ -142,10 +144,10 @@ func (g *Grid) renderEnvironment(envImage *ebiten.Image, refresh bool) {
 }
 
 func (g *Grid) renderPhValue(envImage *ebiten.Image, gridX, gridY int, phVal float64) {
-	x := float64(gridX) * float64(config.GridUnitSize())
-	y := float64(gridY) * float64(config.GridUnitSize())
-	hue := (phVal / config.MaxPh()) * phMaxHue
-	sat := math.Abs(phVal-((config.MaxPh()+config.MinPh())/2.0)) / (config.MaxPh() - config.MinPh())
+	x := float64(gridX) * float64(g.globals.GridUnitSize)
+	y := float64(gridY) * float64(g.globals.GridUnitSize)
+	hue := (phVal / g.globals.MaxPh) * phMaxHue
+	sat := math.Abs(phVal-((g.globals.MaxPh+g.globals.MinPh)/2.0)) / (g.globals.MaxPh - g.globals.MinPh)
 	light := 0.5 + (0.5 * math.Sin(math.Pi*(sat-0.5)))
 	col := colorful.HSLuv(hue, sat, light)
 	g.drawSquare(envImage, x, y, sizeFill, col)
@@ -162,7 +164,7 @@ func (g *Grid) renderFood(foodImage *ebiten.Image, refresh bool) {
 		updatedPoints := g.simulation.GetUpdatedFoodPoints()
 		for _, point := range updatedPoints {
 			// clear square to be updated
-			x, y := point.X*config.GridUnitSize(), point.Y*config.GridUnitSize()
+			x, y := point.X*g.globals.GridUnitSize, point.Y*g.globals.GridUnitSize
 			g.clearSquare(foodImage, float64(x), float64(y))
 
 			if item := g.simulation.GetFoodAtPoint(point); item != nil {
@@ -183,7 +185,7 @@ func (g *Grid) renderOrganisms(organismsImage *ebiten.Image, refresh bool) {
 		updatedPoints := g.simulation.GetUpdatedOrganismPoints()
 		for _, point := range updatedPoints {
 			// clear square to be updated
-			x, y := point.X*config.GridUnitSize(), point.Y*config.GridUnitSize()
+			x, y := point.X*g.globals.GridUnitSize, point.Y*g.globals.GridUnitSize
 			g.clearSquare(organismsImage, float64(x), float64(y))
 
 			if info := g.simulation.GetOrganismInfoAtPoint(point); info != nil {
@@ -217,8 +219,8 @@ func (g *Grid) renderSelections(selectionsImage *ebiten.Image) {
 	}
 }
 
-func newBlankLayer() *ebiten.Image {
-	return ebiten.NewImage(config.GridWidth(), config.GridHeight())
+func (g *Grid) newBlankLayer() *ebiten.Image {
+	return ebiten.NewImage(g.globals.GridWidth, g.globals.GridHeight)
 }
 
 // ChangeMode switches to the next mode listed in viewModes
@@ -234,20 +236,21 @@ func (g *Grid) MouseHover(point utils.Point, onGrid bool) {
 
 // renderSelection draws a square around a single item on the grid
 func (g *Grid) renderSelection(point utils.Point, img *ebiten.Image, col colorful.Color) {
-	x, y := float64(point.X*config.GridUnitSize()), float64(point.Y*config.GridUnitSize())
-	ebitenutil.DrawLine(img, x-2, y-2, x+float64(config.GridUnitSize())+3, y-2, col)                                                               // top
-	ebitenutil.DrawLine(img, x-2, y-2, x-2, y+float64(config.GridUnitSize())+3, col)                                                               // left
-	ebitenutil.DrawLine(img, x-2, y+float64(config.GridUnitSize())+3, x+float64(config.GridUnitSize())+3, y+float64(config.GridUnitSize())+3, col) // bottom
-	ebitenutil.DrawLine(img, x+float64(config.GridUnitSize())+3, y-2, x+float64(config.GridUnitSize())+3, y+float64(config.GridUnitSize())+3, col) // right
+	unitSize := float64(g.globals.GridUnitSize)
+	x, y := float64(point.X)*unitSize, float64(point.Y)*unitSize
+	ebitenutil.DrawLine(img, x-2, y-2, x+unitSize+3, y-2, col)                   // top
+	ebitenutil.DrawLine(img, x-2, y-2, x-2, y+unitSize+3, col)                   // left
+	ebitenutil.DrawLine(img, x-2, y+unitSize+3, x+unitSize+3, y+unitSize+3, col) // bottom
+	ebitenutil.DrawLine(img, x+unitSize+3, y-2, x+unitSize+3, y+unitSize+3, col) // right
 }
 
 func (g *Grid) renderSelectionText(point utils.Point, img *ebiten.Image, message string, col colorful.Color) {
 	xPadding := 10
 	bounds := text.BoundString(resources.FontSourceCodePro10, message)
-	x := xPadding + config.GridUnitSize() + (point.X * config.GridUnitSize())
-	y := point.Y * config.GridUnitSize()
-	if x+bounds.Dx() > config.GridWidth() {
-		x = (point.X * config.GridUnitSize()) - xPadding - bounds.Dx()
+	x := xPadding + g.globals.GridUnitSize + (point.X * g.globals.GridUnitSize)
+	y := point.Y * g.globals.GridUnitSize
+	if x+bounds.Dx() > g.globals.GridWidth {
+		x = (point.X * g.globals.GridUnitSize) - xPadding - bounds.Dx()
 	}
 	text.Draw(img, message, resources.FontSourceCodePro10, x, y, col)
 }
@@ -262,14 +265,14 @@ func (g *Grid) renderViewModeName(img *ebiten.Image) {
 
 // renderFoodItem draws a food item to the given image
 func (g *Grid) renderFoodItem(item *food.Item, img *ebiten.Image) {
-	x := float64(item.Point.X) * float64(config.GridUnitSize())
-	y := float64(item.Point.Y) * float64(config.GridUnitSize())
+	x := float64(item.Point.X) * float64(g.globals.GridUnitSize)
+	y := float64(item.Point.Y) * float64(g.globals.GridUnitSize)
 
 	value := float64(item.Value)
 	foodSize := sizeSmall
-	if value < float64(config.MaxFoodValue())*0.4375 {
+	if value < float64(g.globals.MaxFoodValue)*0.4375 {
 		foodSize = sizeSmall
-	} else if value < float64(config.MaxFoodValue())*0.8125 {
+	} else if value < float64(g.globals.MaxFoodValue)*0.8125 {
 		foodSize = sizeMedium
 	} else {
 		foodSize = sizeLarge
@@ -280,13 +283,13 @@ func (g *Grid) renderFoodItem(item *food.Item, img *ebiten.Image) {
 
 // renderOrganism draws an organism to the given image
 func (g *Grid) renderOrganism(info *organism.Info, img *ebiten.Image) {
-	point := info.Location.Times(config.GridUnitSize())
+	point := info.Location.Times(g.globals.GridUnitSize)
 	x, y := float64(point.X), float64(point.Y)
 
 	organismSize := sizeSmall
-	if info.Size < config.MaximumMaxSize()*0.4375 {
+	if info.Size < g.globals.MaximumMaxSize*0.4375 {
 		organismSize = sizeSmall
-	} else if info.Size < config.MaximumMaxSize()*0.8125 {
+	} else if info.Size < g.globals.MaximumMaxSize*0.8125 {
 		organismSize = sizeMedium
 	} else {
 		organismSize = sizeLarge
@@ -295,7 +298,7 @@ func (g *Grid) renderOrganism(info *organism.Info, img *ebiten.Image) {
 	organismColor := info.Color
 
 	if g.viewMode == phEffectsOnlyMode {
-		maxEffect := config.MaxOrganismPhEffect() * info.Size
+		maxEffect := g.globals.MaxOrganismPhEffect * info.Size
 		spectrumValue := (info.Size*info.PhEffect + maxEffect) / (2 * maxEffect)
 		hue := phMaxHue * spectrumValue
 		sat := 0.5 + math.Abs(spectrumValue-0.5)
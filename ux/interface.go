@@ -1,19 +1,28 @@
 package ux
 
 import (
+	"fmt"
+	"log"
+	"os"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 
 	"github.com/Zebbeni/protozoa/config"
+	d "github.com/Zebbeni/protozoa/decision"
 	"github.com/Zebbeni/protozoa/organism"
 	"github.com/Zebbeni/protozoa/simulation"
 	"github.com/Zebbeni/protozoa/utils"
 )
 
+// exportedTreeCount is the number of most common decision trees exported
+// when no organism is selected
+const exportedTreeCount = 10
+
 type Interface struct {
 	simulation *simulation.Simulation
+	globals    *config.Globals
 	selection  *organism.Info
 
 	grid  *Grid
@@ -28,6 +37,7 @@ type Interface struct {
 func NewInterface(sim *simulation.Simulation) *Interface {
 	i := &Interface{
 		simulation:   sim,
+		globals:      sim.Globals(),
 		grid:         NewGrid(sim),
 		panel:        NewPanel(sim),
 		gridOptions:  &ebiten.DrawImageOptions{},
@@ -68,6 +78,43 @@ func (i *Interface) handleKeyboard() {
 	if inpututil.IsKeyJustReleased(ebiten.KeyM) {
 		i.grid.ChangeMode()
 	}
+	if inpututil.IsKeyJustReleased(ebiten.KeyV) {
+		i.panel.ToggleCarrierVisits()
+	}
+	if inpututil.IsKeyJustReleased(ebiten.KeyT) {
+		i.exportTrees()
+	}
+}
+
+// exportTrees writes the selected organism's decision tree, or the most
+// common trees if no organism with a tree is selected, to DOT and JSON files
+// in the working directory
+func (i *Interface) exportTrees() {
+	name := fmt.Sprintf("trees_cycle_%d", i.simulation.Cycle())
+	trees := d.Exports(i.simulation.GetTopTrees(exportedTreeCount))
+	if export, ok := i.simulation.ExportOrganismTree(i.simulation.GetSelected()); ok {
+		name = fmt.Sprintf("tree_%d_cycle_%d", i.simulation.GetSelected(), i.simulation.Cycle())
+		trees = []d.Export{export}
+	}
+	for _, path := range []string{name + ".dot", name + ".json"} {
+		if err := writeTrees(path, trees); err != nil {
+			log.Printf("failed to export decision trees: %s", err)
+			return
+		}
+	}
+	log.Printf("exported %d decision trees to %s.dot and %s.json", len(trees), name, name)
+}
+
+func writeTrees(path string, trees []d.Export) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.WriteTreesForFile(path, file, trees); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
 }
 
 // eventually let's implement a more comprehensive event handler system
@@ -116,10 +163,10 @@ func (i *Interface) getMouseGridLocation() (utils.Point, bool) {
 	mouseX, mouseY := ebiten.CursorPosition()
 	relativeGridX := mouseX - panelWidth
 	relativeGridY := mouseY
-	gridX := relativeGridX / config.GridUnitSize()
-	gridY := relativeGridY / config.GridUnitSize()
-	gridW := config.GridUnitsWide()
-	gridH := config.GridUnitsHigh()
+	gridX := relativeGridX / i.globals.GridUnitSize
+	gridY := relativeGridY / i.globals.GridUnitSize
+	gridW := i.globals.GridUnitsWide
+	gridH := i.globals.GridUnitsHigh
 	onGrid := gridX >= 0 && gridY >= 0 && gridX < gridW && gridY < gridH
 	return utils.Point{X: gridX, Y: gridY}, onGrid
 }
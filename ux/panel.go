@@ -5,8 +5,14 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/lucasb-eyer/go-colorful"
 	"image/color"
+	"math"
+	"strings"
 
+	"github.com/Zebbeni/protozoa/brain"
+	d "github.com/Zebbeni/protozoa/decision"
+	"github.com/Zebbeni/protozoa/genealogy"
 	r "github.com/Zebbeni/protozoa/resources"
 	s "github.com/Zebbeni/protozoa/simulation"
 )
@@ -24,6 +30,9 @@ const (
 	statsXOffset = padding
 	statsYOffset = 69
 
+	mortalityXOffset = 175
+	mortalityYOffset = statsYOffset
+
 	selectedXOffset = padding
 	selectedYOffset = 300
 
@@ -37,6 +46,10 @@ type Panel struct {
 	simulation         *s.Simulation
 	previousPanelImage *ebiten.Image
 	graph              *Graph
+
+	// showCarrierVisits shades the selected decision tree by the visits of
+	// all organisms carrying it, rather than just the selected organism
+	showCarrierVisits bool
 }
 
 func NewPanel(sim *s.Simulation) *Panel {
@@ -54,6 +67,7 @@ func (p *Panel) Render() *ebiten.Image {
 		p.renderTitle(panelImage)
 		p.renderKeyBindingText(panelImage)
 		p.renderStats(panelImage)
+		p.renderMortality(panelImage)
 		p.renderGraph(panelImage)
 		p.renderSelected(panelImage)
 
@@ -66,6 +80,12 @@ func (p *Panel) Render() *ebiten.Image {
 	return panelImage
 }
 
+// ToggleCarrierVisits switches the decision tree heatmap between the visits
+// of the selected organism and those of all organisms carrying its tree
+func (p *Panel) ToggleCarrierVisits() {
+	p.showCarrierVisits = !p.showCarrierVisits
+}
+
 func (p *Panel) shouldRefresh() bool {
 	return true
 }
@@ -80,9 +100,9 @@ func (p *Panel) renderTitle(panelImage *ebiten.Image) {
 }
 
 func (p *Panel) renderKeyBindingText(panelImage *ebiten.Image) {
-	message := "[Space] to Pause\n[M] to Change Mode"
+	message := "[Space] to Pause\n[M] to Change Mode\n[V] to Change Visits\n[T] to Export Trees"
 	if p.simulation.IsPaused() {
-		message = "[Space] to Resume\n[M] to Change Mode"
+		message = "[Space] to Resume\n[M] to Change Mode\n[V] to Change Visits\n[T] to Export Trees"
 	}
 
 	bounds := text.BoundString(r.FontSourceCodePro10, message)
@@ -96,6 +116,44 @@ func (p *Panel) renderStats(panelImage *ebiten.Image) {
 	text.Draw(panelImage, statsString, r.FontSourceCodePro12, statsXOffset, statsYOffset, color.White)
 }
 
+// causeLabels are the short names of causes of death shown in the panel
+var causeLabels = map[genealogy.Cause]string{
+	genealogy.CauseStarvation: "STARVED",
+	genealogy.CauseAttack:     "ATTACKED",
+	genealogy.CausePh:         "PH STRESS",
+	genealogy.CauseSpawning:   "SPAWNED",
+	genealogy.CauseFeeding:    "FED",
+}
+
+// renderMortality draws the number of deaths of each cause, two to a line
+func (p *Panel) renderMortality(panelImage *ebiten.Image) {
+	total := p.simulation.GetMortality().Total
+	mortalityString := "DEATHS BY CAUSE"
+	for i, cause := range genealogy.Causes {
+		if i%2 == 0 {
+			mortalityString += "\n"
+		} else {
+			mortalityString += "  "
+		}
+		mortalityString += fmt.Sprintf("%-9s %7d", causeLabels[cause], total[cause])
+	}
+	text.Draw(panelImage, mortalityString, r.FontSourceCodePro10, mortalityXOffset, mortalityYOffset, color.White)
+}
+
+// lineageDeathsString lists the percentage of a lineage's deaths of each
+// cause, three to a line
+func lineageDeathsString(counts map[genealogy.Cause]int) string {
+	total := math.Max(float64(genealogy.Sum(counts)), 1)
+	deathsString := ""
+	for i, cause := range genealogy.Causes {
+		if i%3 == 0 {
+			deathsString += "\n "
+		}
+		deathsString += fmt.Sprintf(" %-9s %3.0f%%", causeLabels[cause], 100*float64(counts[cause])/total)
+	}
+	return deathsString
+}
+
 func (p *Panel) renderGraph(panelImage *ebiten.Image) {
 	text.Draw(panelImage, "HISTORY", r.FontSourceCodePro12, graphXOffset, graphYOffset, color.White)
 	graphImage := p.graph.Render()
@@ -120,20 +178,97 @@ func (p *Panel) renderSelected(panelImage *ebiten.Image) {
 	info := p.simulation.GetOrganismInfoByID(id)
 	traits, found := p.simulation.GetOrganismTraitsByID(id)
 
-	decisionTree := p.simulation.GetOrganismDecisionTreeByID(id)
-	if info == nil || decisionTree == nil || found == false {
+	organismBrain := p.simulation.GetOrganismBrainByID(id)
+	if info == nil || organismBrain == nil || found == false {
 		return
 	}
-	decisionTreeString := fmt.Sprintf("DECISION TREE:\n%s", decisionTree.Print())
-
 	infoString := fmt.Sprintf("ORGANISM ID:    %7d       HEALTH:        %3.2f", info.ID, info.Health)
 	infoString += fmt.Sprintf("\nANCESTOR ID:    %7d       SIZE:         %5.2f", info.AncestorID, info.Size)
 	infoString += fmt.Sprintf("\nAGE:            %7d       CHILDREN:   %7d", info.Age, info.Children)
 	infoString += fmt.Sprintf("\nMUTATE CHANCE:     %3.0f%%       SPAWN TIME:   %5d", traits.ChanceToMutateDecisionTree*100.0, traits.MinCyclesBetweenSpawns)
 	infoString += fmt.Sprintf("\nPH TOLERANCE:   %1.1f-%1.1f       PH EFFECT: %1.5f", traits.IdealPh-traits.PhTolerance, traits.IdealPh+traits.PhTolerance, traits.PhEffect)
+	lineageDeaths := p.simulation.GetMortality().Lineage(info.AncestorID)
+	infoString += fmt.Sprintf("\nLINEAGE DEATHS: %7d", genealogy.Sum(lineageDeaths))
+	infoString += lineageDeathsString(lineageDeaths)
+	if flags := p.simulation.Globals().MemoryFlags; flags > 0 {
+		infoString += fmt.Sprintf("\nMEMORY FLAGS: %9s", flagString(info.Flags, flags))
+	}
+	decisionTree, ok := organismBrain.(*brain.Tree)
+	if !ok {
+		bounds := text.BoundString(r.FontSourceCodePro12, infoString)
+		text.Draw(panelImage, infoString, r.FontSourceCodePro12, selectedXOffset, selectedYOffset, color.White)
+		p.renderBrain(panelImage, organismBrain, selectedYOffset+bounds.Dy()+padding)
+		return
+	}
+	if stats, ok := p.simulation.GetTreeStats(decisionTree.ID); ok {
+		infoString += fmt.Sprintf("\nTREE CARRIERS:  %7d       FIRST SEEN: %7d", stats.Carriers, stats.FirstSeen)
+		infoString += fmt.Sprintf("\nTREE BIRTHS:    %7d       CHILDREN:   %7d", stats.Births, stats.Children)
+		infoString += fmt.Sprintf("\nMEAN LIFESPAN:  %7.1f       HEALTH:  %10.2f", stats.MeanLifespan(), stats.HealthGained)
+	}
+	analysis := decisionTree.Analyze()
+	infoString += fmt.Sprintf("\nSIMPLIFIED SIZE: %3d/%-3d     UNREACHABLE: %5d", analysis.SimplifiedSize, analysis.Size, analysis.UnreachableNodes)
+	infoString += fmt.Sprintf("\nREDUNDANT CONDITIONS: %3d", analysis.RedundantConditions)
 	bounds := text.BoundString(r.FontSourceCodePro12, infoString)
 	offsetY := selectedYOffset + bounds.Dy() + padding
 
 	text.Draw(panelImage, infoString, r.FontSourceCodePro12, selectedXOffset, selectedYOffset, color.White)
-	text.Draw(panelImage, decisionTreeString, r.FontSourceCodePro10, selectedXOffset, offsetY, color.White)
+	p.renderDecisionTree(panelImage, decisionTree, offsetY)
+}
+
+// flagString returns the first n memory flags as 1 (set) or 0 (clear), from
+// flag 0 on the left
+func flagString(flags uint8, n int) string {
+	if n > d.MaxMemoryFlags {
+		n = d.MaxMemoryFlags
+	}
+	var builder strings.Builder
+	for flag := 0; flag < n; flag++ {
+		if flag > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(fmt.Sprint((flags >> flag) & 1))
+	}
+	return builder.String()
+}
+
+// renderBrain draws the description of a brain that isn't a decision tree
+func (p *Panel) renderBrain(panelImage *ebiten.Image, organismBrain brain.Brain, offsetY int) {
+	text.Draw(panelImage, "BRAIN:", r.FontSourceCodePro10, selectedXOffset, offsetY, color.White)
+	lineHeight := r.FontSourceCodePro10.Metrics().Height.Ceil()
+	description := strings.TrimSuffix(organismBrain.Describe(), "\n")
+	text.Draw(panelImage, description, r.FontSourceCodePro10, selectedXOffset, offsetY+lineHeight, color.White)
+}
+
+// renderDecisionTree draws each line of the organism's decision tree shaded
+// by how often its node has been visited, relative to the root
+func (p *Panel) renderDecisionTree(panelImage *ebiten.Image, decisionTree *brain.Tree, offsetY int) {
+	title := "DECISION TREE (ORGANISM VISITS):"
+	visits := decisionTree.Trace().Visits
+	if p.showCarrierVisits {
+		title = "DECISION TREE (ALL CARRIER VISITS):"
+		if entry, ok := p.simulation.GetTreeLibrary()[decisionTree.ID]; ok && entry.Tree != nil {
+			visits = entry.Visits
+		}
+	}
+	text.Draw(panelImage, title, r.FontSourceCodePro10, selectedXOffset, offsetY, color.White)
+
+	lineHeight := r.FontSourceCodePro10.Metrics().Height.Ceil()
+	lines := strings.Split(strings.TrimSuffix(decisionTree.Describe(), "\n"), "\n")
+	for i, line := range lines {
+		fraction := 0.0
+		if visits[0] > 0 {
+			fraction = float64(visits[i]) / float64(visits[0])
+		}
+		line = fmt.Sprintf("%s  %3.0f%%", line, fraction*100)
+		text.Draw(panelImage, line, r.FontSourceCodePro10, selectedXOffset, offsetY+(i+1)*lineHeight, visitColor(fraction))
+	}
+}
+
+// visitColor returns gray for nodes never visited, and otherwise a color
+// from blue (rarely visited) to red (always visited)
+func visitColor(fraction float64) color.Color {
+	if fraction == 0 {
+		return colorful.Hsv(0, 0, 0.4)
+	}
+	return colorful.Hsv(240*(1-fraction), 0.7, 1)
 }